@@ -0,0 +1,146 @@
+package blog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// formats of access log
+const (
+	LogCommon string = "common"
+	LogJSON   string = "json"
+)
+
+// accessLog is middleware with logging of each request
+type accessLog struct {
+	next   http.Handler
+	format string
+	// errorLog is logger of errors of writing to output
+	errorLog *log.Logger
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewAccessLog return handler with logging of requests to output
+// in common log format or as JSON lines with duration and error of
+// request. Errors of requests in common log format and errors of
+// writing to output are logged by errorLog or to standard error
+// if it is nil.
+func NewAccessLog(next http.Handler, out io.Writer, format string, errorLog *log.Logger) (http.Handler, error) {
+	if format != LogCommon && format != LogJSON {
+		return nil, fmt.Errorf("unknown format of access log `%s`", format)
+	}
+	if errorLog == nil {
+		errorLog = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &accessLog{next: next, format: format, out: out, errorLog: errorLog}, nil
+}
+
+// logWriter is response writer with recording of status code,
+// size of response and error of handler
+type logWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+	err    error
+}
+
+func (w *logWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush send buffered data to client for streams of events
+func (w *logWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// recordError save error of handler for access log
+func (w *logWriter) recordError(err error) {
+	w.err = err
+}
+
+// logEntry is record of access log
+type logEntry struct {
+	Time     time.Time `json:"time"`
+	Remote   string    `json:"remote"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Proto    string    `json:"proto"`
+	Status   int       `json:"status"`
+	Size     int       `json:"size"`
+	Duration float64   `json:"duration_ms"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (l *accessLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	lw := &logWriter{ResponseWriter: w}
+	l.next.ServeHTTP(lw, r)
+
+	e := logEntry{
+		Time:     start,
+		Remote:   r.RemoteAddr,
+		Method:   r.Method,
+		Path:     r.URL.RequestURI(),
+		Proto:    r.Proto,
+		Status:   lw.status,
+		Size:     lw.size,
+		Duration: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		e.Remote = host
+	}
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	if lw.err != nil {
+		e.Error = lw.err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.write(e); err != nil {
+		l.errorLog.Printf("cannot write access log : %v", err)
+	}
+}
+
+// write entry to access log
+func (l *accessLog) write(e logEntry) error {
+	if l.format == LogJSON {
+		return json.NewEncoder(l.out).Encode(e)
+	}
+	size := "-"
+	if 0 < e.Size {
+		size = fmt.Sprintf("%d", e.Size)
+	}
+	_, err := fmt.Fprintf(l.out, "%s - - [%s] %q %d %s\n",
+		e.Remote, e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method+" "+e.Path+" "+e.Proto, e.Status, size)
+	if e.Error != "" {
+		// common log format has not field for error
+		l.errorLog.Printf("Error : %d : %s %s : %s", e.Status, e.Method, e.Path, e.Error)
+	}
+	return err
+}
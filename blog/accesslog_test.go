@@ -0,0 +1,127 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		s.writeError(w, r, errorf(http.StatusNotFound, "secret cause"))
+	})
+
+	tcs := []struct {
+		url    string
+		expect string
+		errors string
+	}{
+		{
+			url:    "/ok?q=1",
+			expect: `^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /ok\?q=1 HTTP/1\.1" 200 5$`,
+		},
+		{
+			url:    "/fail",
+			expect: `^192\.0\.2\.1 - - \[.*\] "GET /fail HTTP/1\.1" 404 \d+$`,
+			errors: "Error : 404 : GET /fail : secret cause\n",
+		},
+		{
+			url:    "/not-exist",
+			expect: `"GET /not-exist HTTP/1\.1" 404 19$`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.url, func(t *testing.T) {
+			var buf, errs bytes.Buffer
+			l, err := NewAccessLog(mux, &buf, LogCommon, log.New(&errs, "", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tc.url, nil))
+			line := strings.TrimSuffix(buf.String(), "\n")
+			if !regexp.MustCompile(tc.expect).MatchString(line) {
+				t.Errorf("not valid log line:\n%s\n%s", line, tc.expect)
+			}
+			if errs.String() != tc.errors {
+				t.Errorf("not valid error log: %q", errs.String())
+			}
+		})
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	var buf bytes.Buffer
+	l, err := NewAccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.writeError(w, r, errorf(http.StatusForbidden, "secret cause"))
+	}), &buf, LogJSON, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	l.ServeHTTP(w, httptest.NewRequest("POST", "/a", nil))
+
+	if strings.Contains(w.Body.String(), "secret cause") {
+		t.Errorf("cause of error is sent to client")
+	}
+	var e logEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Method != "POST" || e.Path != "/a" || e.Status != http.StatusForbidden ||
+		e.Size != w.Body.Len() || e.Remote != "192.0.2.1" || e.Error != "secret cause" ||
+		e.Time.IsZero() || e.Duration < 0 {
+		t.Errorf("not valid entry: %#v", e)
+	}
+}
+
+func TestAccessLogFormat(t *testing.T) {
+	if _, err := NewAccessLog(nil, nil, "xml", nil); err == nil {
+		t.Errorf("unknown format is accepted")
+	}
+}
+
+func TestAccessLogFlush(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewAccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Errorf("response writer is not flusher")
+		}
+	}), &buf, LogCommon, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reload", nil))
+}
+
+// failWriter is writer with error for each write
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk is full") }
+
+func TestAccessLogError(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewAccessLog(http.NotFoundHandler(), failWriter{}, LogCommon, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if out := buf.String(); out != "cannot write access log : disk is full\n" {
+		t.Errorf("not valid error log: %q", out)
+	}
+}
@@ -0,0 +1,182 @@
+package blog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// albumReadme is name of file with description of album
+const albumReadme string = "README.md"
+
+// album is folder of photos with nested albums
+type album struct {
+	// path is path of album inside photos folder separated by slash.
+	// Path of root album is empty.
+	path string
+	// albums is names of nested albums
+	albums []string
+	// photos is sorted photos and other assets of album
+	photos []photoInfo
+	// description is markdown from README.md of album
+	description []byte
+}
+
+// albumFolder return folder of album on disk
+func (s *Server) albumFolder(albumPath string) string {
+	return s.file(path.Join(s.opts.Photos, albumPath))
+}
+
+// albumName return name of album for title of page
+func albumName(albumPath string) string {
+	if albumPath == "" {
+		return "Photos"
+	}
+	return path.Base(albumPath)
+}
+
+// readAlbum return album by path inside photos folder
+func (s *Server) readAlbum(albumPath string) (a album, err error) {
+	a.path = albumPath
+	dir := s.albumFolder(albumPath)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() && !s.isIgnored(file.Name()) {
+			a.albums = append(a.albums, file.Name())
+		}
+	}
+	if a.photos, err = s.infos.readPhotos(dir); err != nil {
+		return
+	}
+	a.description, err = ioutil.ReadFile(filepath.Join(dir, albumReadme))
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return
+}
+
+// albumCover return album path and name of cover image. Image with
+// name "cover" is used as cover, otherwise first image of album or
+// cover of first nested album. Return false if album has not images.
+func (s *Server) albumCover(albumPath string) (coverAlbum, name string, ok bool) {
+	a, err := s.readAlbum(albumPath)
+	if err != nil {
+		return
+	}
+	for _, p := range a.photos {
+		if hasThumb(p.name) && strings.TrimSuffix(p.name, filepath.Ext(p.name)) == "cover" {
+			return albumPath, p.name, true
+		}
+	}
+	for _, p := range a.photos {
+		if hasThumb(p.name) {
+			return albumPath, p.name, true
+		}
+	}
+	for _, sub := range a.albums {
+		if coverAlbum, name, ok = s.albumCover(path.Join(albumPath, sub)); ok {
+			return
+		}
+	}
+	return
+}
+
+// albumBreadcrumbs return links from main page to album
+func albumBreadcrumbs(albumPath string, l links) (bs []pageLink) {
+	bs = append(bs, pageLink{Name: "Main page", URL: l.main()})
+	bs = append(bs, pageLink{Name: albumName(""), URL: l.album("")})
+	if albumPath == "" {
+		return
+	}
+	parts := strings.Split(albumPath, "/")
+	for i := range parts {
+		bs = append(bs, pageLink{
+			Name: parts[i],
+			URL:  l.album(strings.Join(parts[:i+1], "/")),
+		})
+	}
+	return
+}
+
+// albumsList return markdown of nested albums with covers
+func (s *Server) albumsList(parent string, names []string, l links) (content string) {
+	for _, name := range names {
+		albumPath := path.Join(parent, name)
+		if coverAlbum, cover, ok := s.albumCover(albumPath); ok {
+			content += fmt.Sprintf("[![%s](%s)](%s)\n\n",
+				name, l.thumb(coverAlbum, cover), l.album(albumPath))
+		}
+		content += fmt.Sprintf("[%s](%s)\n\n", name, l.album(albumPath))
+	}
+	return
+}
+
+// albumPage generate markdown of photo album page with breadcrumbs,
+// description, nested albums and photos. Each image has caption with
+// capture date.
+func (s *Server) albumPage(a album, l links) string {
+	var content string
+	bs := albumBreadcrumbs(a.path, l)
+	for i, b := range bs {
+		if i == len(bs)-1 {
+			content += b.Name + "\n\n"
+			break
+		}
+		content += fmt.Sprintf("[%s](%s) / ", b.Name, b.URL)
+	}
+	if 0 < len(a.description) {
+		content += string(a.description) + "\n\n"
+	}
+	if 0 < len(a.albums) {
+		content += "## Albums\n\n"
+		content += s.albumsList(a.path, a.albums, l)
+		content += "------\n\n"
+	}
+	for _, p := range a.photos {
+		if !hasThumb(p.name) {
+			content += fmt.Sprintf("[![%s](%s)](%s)\n\n",
+				p.name,
+				l.photo(a.path, p.name),
+				l.view(a.path, p.name),
+			)
+			continue
+		}
+		// camera and size in title of image
+		var title []string
+		if p.camera != "" {
+			title = append(title, strings.Replace(p.camera, "\"", "", -1))
+		}
+		if 0 < p.width && 0 < p.height {
+			title = append(title, fmt.Sprintf("%dx%d", p.width, p.height))
+		}
+		var titleAttr string
+		if 0 < len(title) {
+			titleAttr = fmt.Sprintf(" \"%s\"", strings.Join(title, ", "))
+		}
+		// thumbnail with link to view page
+		content += fmt.Sprintf("[![%s](%s%s)](%s)\n\n",
+			p.name,
+			l.thumb(a.path, p.name),
+			titleAttr,
+			l.view(a.path, p.name),
+		)
+		if !p.date.IsZero() {
+			content += fmt.Sprintf("*%s*\n\n", p.date.Format("2006-01-02 15:04"))
+		}
+	}
+	return content
+}
+
+// albumPageData return data of album page for layout templates
+func albumPageData(a album, content []byte, l links) pageData {
+	data := newPage("gallery", albumName(a.path), content, l)
+	data.Breadcrumbs = albumBreadcrumbs(a.path, l)
+	data.Breadcrumbs[len(data.Breadcrumbs)-1].URL = ""
+	return data
+}
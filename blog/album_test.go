@@ -0,0 +1,220 @@
+package blog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createAlbums create tree of nested albums in photos folder
+func createAlbums(t *testing.T, dir string) {
+	t.Helper()
+	createTree(t, dir, map[string]string{
+		"photos/travel/README.md":            "Trips *around* the world\n",
+		"photos/travel/map.txt":              "map",
+		"photos/travel/2019/italy/a.jpg":     "jpg",
+		"photos/travel/2019/italy/cover.png": "png",
+		"photos/travel/2019/italy/z.jpg":     "jpg",
+		"photos/travel/2020/b.jpg":           "jpg",
+		"photos/empty/.hidden.jpg":           "jpg",
+		"photos/root.jpg":                    "jpg",
+	})
+}
+
+func TestAlbumCover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-album")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	createAlbums(t, dir)
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	tcs := []struct {
+		album string
+		cover string
+	}{
+		{"travel/2019/italy", "travel/2019/italy/cover.png"},
+		{"travel/2020", "travel/2020/b.jpg"},
+		{"travel/2019", "travel/2019/italy/cover.png"},
+		{"travel", "travel/2019/italy/cover.png"},
+		{"empty", ""},
+		{"not-exist", ""},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.album, func(t *testing.T) {
+			album, name, ok := s.albumCover(tc.album)
+			var cover string
+			if ok {
+				cover = album + "/" + name
+			}
+			if cover != tc.cover {
+				t.Errorf("not valid cover: %s", cover)
+			}
+		})
+	}
+}
+
+func TestAlbumBreadcrumbs(t *testing.T) {
+	bs := albumBreadcrumbs("travel/2019/italy", serverLinks{photos: "photos"})
+	var out []string
+	for _, b := range bs {
+		out = append(out, b.Name+"="+b.URL)
+	}
+	expect := "Main page=/,Photos=/photos/,travel=/photos/travel," +
+		"2019=/photos/travel/2019,italy=/photos/travel/2019/italy"
+	if s := strings.Join(out, ","); s != expect {
+		t.Errorf("not valid breadcrumbs:\n%s\n%s", s, expect)
+	}
+}
+
+func TestAlbumHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-album")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	createAlbums(t, dir)
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	tcs := []struct {
+		url         string
+		status      int
+		contains    []string
+		notContains []string
+	}{
+		{
+			url:    "/photos/",
+			status: http.StatusOK,
+			contains: []string{
+				`<a href="/">Main page</a> / Photos`,
+				`<a href="/photos/travel"><img src="/thumbs/travel/2019/italy/cover.png" alt="travel"`,
+				`<a href="/photos/empty">empty</a>`,
+				`<a href="/view/root.jpg"><img src="/thumbs/root.jpg"`,
+			},
+		},
+		{
+			url:    "/photos/travel",
+			status: http.StatusOK,
+			contains: []string{
+				`<a href="/photos/">Photos</a> / travel`,
+				`Trips <em>around</em> the world`,
+				`<a href="/photos/travel/2019">2019</a>`,
+				`<a href="/photos/travel/2020"><img src="/thumbs/travel/2020/b.jpg"`,
+				`<img src="/photos/travel/map.txt" alt="map.txt"`,
+			},
+			notContains: []string{"README.md"},
+		},
+		{
+			url:    "/photos/travel/2019/italy",
+			status: http.StatusOK,
+			contains: []string{
+				`<a href="/photos/travel">travel</a> / <a href="/photos/travel/2019">2019</a> / italy`,
+				`<a href="/view/travel/2019/italy/a.jpg"><img src="/thumbs/travel/2019/italy/a.jpg"`,
+			},
+			notContains: []string{"<h2>Albums</h2>"},
+		},
+		{
+			url:      "/photos/travel/2019/italy/a.jpg",
+			status:   http.StatusOK,
+			contains: []string{"jpg"},
+		},
+		{
+			url:    "/photos/travel/README.md",
+			status: http.StatusForbidden,
+		},
+		{
+			url:    "/photos/not-exist",
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.photosHandler(w, httptest.NewRequest("GET", tc.url, nil))
+			if w.Code != tc.status {
+				t.Fatalf("not valid status: %d", w.Code)
+			}
+			for _, c := range tc.contains {
+				if !strings.Contains(w.Body.String(), c) {
+					t.Errorf("cannot find `%s` in:\n%s", c, w.Body.String())
+				}
+			}
+			for _, c := range tc.notContains {
+				if strings.Contains(w.Body.String(), c) {
+					t.Errorf("found `%s` in:\n%s", c, w.Body.String())
+				}
+			}
+		})
+	}
+
+	// main page with covers of albums
+	content, err := s.mainPage(s.links())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{
+		"[![travel](/thumbs/travel/2019/italy/cover.png)](/photos/travel)",
+		"[All photos](/photos/)",
+	} {
+		if !strings.Contains(content, c) {
+			t.Errorf("cannot find `%s` in:\n%s", c, content)
+		}
+	}
+}
+
+func TestBuildAlbums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-album")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	createAlbums(t, dir)
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	if err := s.Build(filepath.Join(dir, "public")); err != nil {
+		t.Fatal(err)
+	}
+	tcs := []struct {
+		filename string
+		contains []string
+	}{
+		{
+			filename: "public/photos/index.html",
+			contains: []string{`href="../index.html"`, `href="../photos/travel/index.html"`},
+		},
+		{
+			filename: "public/photos/travel/2019/italy/index.html",
+			contains: []string{
+				`href="../../../../photos/travel/2019/index.html"`,
+				`src="../../../../thumbs/travel/2019/italy/a.jpg"`,
+			},
+		},
+		{filename: "public/photos/travel/2019/italy/a.jpg", contains: []string{"jpg"}},
+		{filename: "public/thumbs/travel/2019/italy/a.jpg", contains: []string{"jpg"}},
+		{filename: "public/photos/root.jpg", contains: []string{"jpg"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.filename, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(tc.filename)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tc.contains {
+				if !strings.Contains(string(content), c) {
+					t.Errorf("cannot find `%s` in:\n%s", c, string(content))
+				}
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "public", "photos", "travel", "README.md")); err == nil {
+		t.Errorf("description of album is copied")
+	}
+}
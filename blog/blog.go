@@ -0,0 +1,662 @@
+// Package blog serve markdown articles and photo albums from folder.
+//
+//	b, err := blog.New(blog.Options{Root: "notes"})
+//	if err != nil {
+//		return err
+//	}
+//	defer b.Close()
+//	http.Handle("/", b)
+package blog
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/russross/blackfriday"
+)
+
+// Windows OS specific variable name
+const windowsOs string = "windows"
+
+// Options is settings of blog. Zero value of optional fields
+// is replaced by default value.
+type Options struct {
+	// Root is folder with articles and photos
+	Root string
+	// Title is title of main page and feeds
+	Title string
+	// URL is base URL of blog used in feeds. If it is empty, then
+	// address of request is used.
+	URL string
+	// Extensions is extensions of markdown parser
+	Extensions blackfriday.Extensions
+	// Theme is folder with html templates of pages. Built-in template
+	// is used if it is empty.
+	Theme string
+	// Photos is name of photos folder inside root folder
+	Photos string
+	// Ignore is names of ignored folders. Hidden folders like ".git"
+	// are always ignored.
+	Ignore []string
+	// FeedSize is maximal amount of articles in feeds
+	FeedSize int
+	// PollInterval is interval of checking changes of files on disk
+	// for live reload
+	PollInterval time.Duration
+	// RescanInterval is interval of rescanning list of articles
+	// without live reload. Articles are checked by modification time
+	// on each request.
+	RescanInterval time.Duration
+	// Drafts is true for show draft articles
+	Drafts bool
+	// LiveReload is true for reload opened pages after changes of files
+	LiveReload bool
+	// NoSearch disable search of articles
+	NoSearch bool
+	// NoFeeds disable RSS and Atom feeds
+	NoFeeds bool
+	// NoHighlight disable syntax highlighting of code blocks
+	NoHighlight bool
+	// NoTOC disable table of contents of articles
+	NoTOC bool
+	// NoMath disable rendering of formulas $...$ and $$...$$ to MathML
+	NoMath bool
+	// ErrorLog is logger of errors of requests without access log and
+	// errors of background checks. Standard error is used if it is nil.
+	ErrorLog *log.Logger
+}
+
+// Server is blog with articles and photos from root folder.
+// Server implements http.Handler.
+type Server struct {
+	opts    Options
+	ignored map[string]bool
+
+	// theme is layout templates by kind of page.
+	// Template "base" is used for other kinds.
+	// Built-in template tmpl is used if theme is nil.
+	theme map[string]*template.Template
+	// bodyEnd is html added at the end of body for each page
+	bodyEnd string
+
+	cache   *cache
+	thumbs  *thumbCache
+	infos   *photoCache
+	watcher *watcher
+	mux     *http.ServeMux
+}
+
+// New return blog server with options. Server with live reload checks
+// changes of files in background until Close.
+func New(opts Options) (s *Server, err error) {
+	if opts.Root == "" {
+		opts.Root = "."
+	}
+	if opts.Title == "" {
+		opts.Title = "List of articles"
+	}
+	if opts.Extensions == 0 {
+		opts.Extensions = blackfriday.CommonExtensions | blackfriday.AutoHeadingIDs
+	}
+	if opts.Photos == "" {
+		opts.Photos = "photos"
+	}
+	if opts.FeedSize == 0 {
+		opts.FeedSize = 20
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 500 * time.Millisecond
+	}
+	if opts.RescanInterval == 0 {
+		opts.RescanInterval = 10 * time.Second
+	}
+	if opts.ErrorLog == nil {
+		opts.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	opts.URL = strings.TrimSuffix(opts.URL, "/")
+
+	s = &Server{
+		opts:    opts,
+		ignored: map[string]bool{},
+		thumbs:  &thumbCache{entries: map[string]*thumbEntry{}},
+		infos:   &photoCache{entries: map[string]*photoEntry{}},
+		mux:     http.NewServeMux(),
+	}
+	for _, name := range opts.Ignore {
+		s.ignored[name] = true
+	}
+	s.cache = newCache(s)
+	if opts.Theme != "" {
+		if s.theme, err = loadTheme(opts.Theme); err != nil {
+			return nil, err
+		}
+	}
+
+	// watch changes of files for live reload and update cache of articles
+	if opts.LiveReload {
+		if s.watcher, err = newWatcher(opts.Root, s.isIgnored); err != nil {
+			return nil, fmt.Errorf("Cannot watch files: %v", err)
+		}
+		s.watcher.onChange = s.cache.invalidate
+		s.watcher.errorLog = opts.ErrorLog
+		go s.watcher.run(opts.PollInterval)
+	}
+
+	// generate main page
+	s.mux.HandleFunc("/", s.mainHandler)
+	// generate articles
+	s.mux.HandleFunc("/articles/", s.articleHandler)
+	// generate photos
+	s.mux.HandleFunc("/"+opts.Photos+"/", s.photosHandler)
+	// thumbnails of photos
+	s.mux.HandleFunc("/"+thumbs+"/", s.thumbHandler)
+	// view pages of photos
+	s.mux.HandleFunc("/"+views+"/", s.viewHandler)
+	// tags of articles
+	s.mux.HandleFunc("/tags/", s.tagsHandler)
+	// search articles
+	if !opts.NoSearch {
+		s.mux.HandleFunc("/search", s.searchHandler)
+	}
+	// feeds of recent articles
+	if !opts.NoFeeds {
+		s.mux.HandleFunc("/feed.xml", s.feedHandler("rss"))
+		s.mux.HandleFunc("/atom.xml", s.feedHandler("atom"))
+	}
+	// live reload
+	if opts.LiveReload {
+		s.mux.Handle(reload, s.watcher)
+		s.bodyEnd = reloadScript
+	}
+	return s, nil
+}
+
+// ServeHTTP serve pages of blog
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close stop checking changes of files and all streams of
+// live reload events
+func (s *Server) Close() error {
+	if s.watcher != nil {
+		s.watcher.close()
+	}
+	return nil
+}
+
+// file return name of file on disk by path inside root folder
+func (s *Server) file(path string) string {
+	return filepath.Join(s.opts.Root, filepath.FromSlash(path))
+}
+
+// links return links of pages served by server
+func (s *Server) links() links {
+	return serverLinks{photos: s.opts.Photos, noSearch: s.opts.NoSearch}
+}
+
+var tmpl = `
+<html>
+	<head>
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<title>%s</title>
+		<style>
+			.markdown-body {
+				box-sizing: border-box;
+				min-width: 200px;
+				max-width: 900px;
+				margin: 0 auto;
+				padding: 45px;
+			}
+			@media (max-width: 767px) {
+				.markdown-body {
+					padding: 15px;
+				}
+			}
+			img{
+				max-height:500px;
+				max-width:500px;
+				height:auto;
+				width:auto;
+			}
+			.wiki-missing {
+				color: #cb2431;
+				border-bottom: 1px dashed #cb2431;
+			}
+			.backlinks {
+				margin-top: 2em;
+				border-top: 1px solid #eaecef;
+			}` + highlightCSS + `
+	</style>
+	</head>
+	<body>
+		<article class="markdown-body">
+			%s
+		</article>
+	</body>
+</html>`
+
+// links generate addresses of blog pages
+type links interface {
+	// main return link to main page
+	main() string
+	// article return link to article or asset file
+	article(path string) string
+	// album return link to photo album by path inside photos folder.
+	// Empty path is root album.
+	album(name string) string
+	// photo return link to photo in album
+	photo(album, name string) string
+	// thumb return link to thumbnail of photo in album
+	thumb(album, name string) string
+	// view return link to view page of photo in album
+	view(album, name string) string
+	// tag return link to page with articles of tag.
+	// Empty tag is page with all tags.
+	tag(name string) string
+	// search return link to search page or empty string
+	// if search is not supported
+	search() string
+}
+
+// serverLinks is links of pages served by web server
+type serverLinks struct {
+	// photos is name of photos folder
+	photos string
+	// noSearch is true if search is disabled
+	noSearch bool
+}
+
+func (serverLinks) main() string { return "/" }
+
+func (serverLinks) article(path string) string {
+	// escape space
+	return "/articles/" + url.QueryEscape(path)
+}
+
+func (l serverLinks) album(name string) string {
+	if name == "" {
+		return "/" + l.photos + "/"
+	}
+	return "/" + path.Join(l.photos, name)
+}
+
+func (l serverLinks) photo(album, name string) string {
+	return "/" + path.Join(l.photos, album, name)
+}
+
+func (serverLinks) thumb(album, name string) string {
+	return "/" + path.Join(thumbs, album, name)
+}
+
+func (serverLinks) view(album, name string) string {
+	return "/" + path.Join(views, album, name)
+}
+
+func (serverLinks) tag(name string) string {
+	return "/tags/" + url.PathEscape(tagName(name))
+}
+
+func (l serverLinks) search() string {
+	if l.noSearch {
+		return ""
+	}
+	return "/search"
+}
+
+// mainHandler generate main web page with list of articles
+func (s *Server) mainHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try open page: %v. %v", r.URL.Path, err)
+			}
+		}()
+		// main page is mounted at "/" and handles all unknown addresses
+		if r.URL.Path != "/" {
+			return errorf(http.StatusNotFound, "Page is not found")
+		}
+		l := s.links()
+		mainTmpl, err := s.mainPage(l)
+		if err != nil {
+			return err
+		}
+
+		// generate html by markdown
+		html := s.render([]byte(mainTmpl))
+		out, err := s.page(newPage("index", s.opts.Title, html, l))
+		if err != nil {
+			return err
+		}
+		w.Write(out)
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
+
+// getFolders return list of all folders inside base folder recursive.
+// Base folder is path inside root folder.
+// Hidden folders like ".git" and folders from options are ignored.
+// Photos folder is ignored, because photos and descriptions of albums
+// are shown on album pages.
+func (s *Server) getFolders(baseFolder string) (fs []string, err error) {
+	files, err := ioutil.ReadDir(s.file(baseFolder))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if s.isIgnored(file.Name()) {
+			continue
+		}
+		if baseFolder == "." && file.Name() == s.opts.Photos {
+			continue
+		}
+		fs = append(fs, baseFolder+string(os.PathSeparator)+file.Name())
+	}
+	size := len(fs)
+	for i := 0; i < size; i++ {
+		fss, err := s.getFolders(fs[i])
+		if err != nil {
+			return nil, err
+		}
+		fs = append(fs, fss...)
+	}
+
+	return fs, nil
+}
+
+// mainPage generate markdown of main page with list of articles
+func (s *Server) mainPage(l links) (mainTmpl string, err error) {
+	// generate markdown main page
+	mainTmpl = fmt.Sprintf("# %s:\n\n", s.opts.Title)
+	if action := l.search(); action != "" {
+		mainTmpl += searchForm(action, "") + "\n\n"
+	}
+	folders, err := s.cache.list()
+	if err != nil {
+		return
+	}
+
+	for _, f := range folders {
+		var folderHeader bool
+		for _, a := range f.articles {
+			if a.isDraft() && !s.opts.Drafts {
+				continue
+			}
+			if !folderHeader {
+				folderHeader = true
+				mainTmpl += "------\n\n"
+				count := strings.Count(f.path, "\\")
+				count += strings.Count(f.path, "/")
+				count++
+				for i := 0; i < count && i < 3; i++ {
+					mainTmpl += "#"
+				}
+				path := f.path
+				if runtime.GOOS == windowsOs {
+					path = strings.Replace(path, "\\", "/", -1)
+				}
+				mainTmpl += fmt.Sprintf(" %s\n\n", path)
+			}
+
+			// add to main page
+			mainTmpl += fmt.Sprintf("[%s](%s)\n\n", a.name, l.article(a.path))
+			if a.meta.Summary != "" {
+				mainTmpl += fmt.Sprintf("%s\n\n", a.meta.Summary)
+			}
+			mainTmpl += "\n\n"
+		}
+	}
+	mainTmpl += "------\n\n"
+	mainTmpl += fmt.Sprintf("[Tags](%s)\n\n", l.tag(""))
+	mainTmpl += "------\n\n"
+
+	// photos
+	func() {
+		a, err := s.readAlbum("")
+		if err != nil {
+			return
+		}
+		mainTmpl += fmt.Sprintf("# PHOTOS\n\n")
+		mainTmpl += s.albumsList("", a.albums, l)
+		mainTmpl += fmt.Sprintf("[All photos](%s)\n\n", l.album(""))
+		mainTmpl += "------\n\n"
+	}()
+
+	return mainTmpl, nil
+}
+
+// article is information about markdown article
+type article struct {
+	// path is path to markdown file with slash separators
+	path string
+	// name is title of article
+	name string
+	// meta is metadata from front matter
+	meta meta
+}
+
+// readArticle read markdown file and return information about article
+// and markdown body without front matter. Path is path of article inside
+// root folder, filename is name of file on disk.
+func readArticle(path, filename string) (a article, body []byte, err error) {
+	a.path = path
+	a.name = path
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	a.meta, body, err = parseFrontMatter(content)
+	if err != nil {
+		return
+	}
+	if a.meta.Title != "" {
+		a.name = a.meta.Title
+		return
+	}
+
+	// name of article is first line
+	title := string(body)
+	if 200 < len(title) {
+		title = title[:200]
+	}
+	index := strings.Index(title, "\n")
+	if index > 0 {
+		if title = strings.TrimSpace(title[:index]); title != "" {
+			a.name = title
+			a.name = strings.ReplaceAll(a.name, "#", " ")
+			a.name = strings.TrimSpace(a.name)
+		}
+	}
+	return
+}
+
+// isDraft return true for draft article.
+// Draft article have flag "draft" in front matter or
+// name of file with suffix ".draft.md".
+func (a article) isDraft() bool {
+	return a.meta.Draft || strings.HasSuffix(a.path, ".draft.md")
+}
+
+// sortArticles sort articles by date from newest to oldest.
+// Articles without date are located after and sorted by path.
+func sortArticles(articles []article) {
+	sort.SliceStable(articles, func(i, j int) bool {
+		di, dj := articles[i].meta.Date, articles[j].meta.Date
+		if di.IsZero() != dj.IsZero() {
+			return !di.IsZero()
+		}
+		if !di.Equal(dj) {
+			return di.After(dj)
+		}
+		return articles[i].path < articles[j].path
+	})
+}
+
+// articleHandler generate web page with article
+func (s *Server) articleHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try open page in article: %v. %v", r.URL.Path, err)
+			}
+		}()
+		// get title
+		path := r.URL.Path
+		if len(path) <= len("/articles/") {
+			err = errorf(http.StatusBadRequest, "URL path is too small: %s", path)
+			return
+		}
+		title := path[len("/articles/")-1:]
+		title = strings.TrimSpace(title)
+		if title == "" {
+			err = errorf(http.StatusBadRequest, "Title of article is empty")
+			return
+		}
+		// Unescape url
+		title, err = url.QueryUnescape(title)
+		if title == "" {
+			err = errorf(http.StatusBadRequest, "Cannot unescape : %v", err)
+			return
+		}
+
+		// find file inside root folder
+		title, info, err := s.resolve(".", title)
+		if err != nil {
+			return
+		}
+		if info.IsDir() {
+			err = errorf(http.StatusForbidden, "Cannot show folder `%s`", title)
+			return
+		}
+
+		// get file content
+		if strings.HasSuffix(title, ".md") {
+			var e *cacheEntry
+			e, err = s.cache.article(title)
+			if err != nil {
+				err = fileError(err, "Cannot read file `%s`", title)
+				return
+			}
+			a := e.article
+			if a.isDraft() && !s.opts.Drafts {
+				err = errorf(http.StatusNotFound, "Article `%s` is draft", title)
+				return
+			}
+			// generate markdown
+			data := newPage("article", a.name, e.html, s.links())
+			data.Meta = a.meta
+			data.TOC = template.HTML(e.toc)
+			var out []byte
+			if out, err = s.page(data); err != nil {
+				return
+			}
+			w.Write(out)
+		} else {
+			if !isAsset(title) {
+				err = errorf(http.StatusForbidden, "File `%s` is not allowed asset", title)
+				return
+			}
+			http.ServeFile(w, r, s.file(title))
+		}
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
+
+// articleHeader generate markdown of links at the top of article page
+func articleHeader(a article, l links) string {
+	// add link to main page
+	str := fmt.Sprintf("[Main page](%s)\n\n", l.main())
+
+	// add links to tags
+	if 0 < len(a.meta.Tags) {
+		var tags []string
+		for _, tag := range a.meta.Tags {
+			tags = append(tags, fmt.Sprintf("[%s](%s)", tagName(tag), l.tag(tag)))
+		}
+		str += fmt.Sprintf("Tags: %s\n\n", strings.Join(tags, ", "))
+	}
+
+	return str
+}
+
+// articleHTML return html of article with resolved wiki links and
+// backlinks at the bottom, and html of table of contents.
+// Relative links of article are changed to addresses of served files.
+func (s *Server) articleHTML(a article, body []byte, w *wiki, l links) (html, toc []byte) {
+	html = s.render([]byte(articleHeader(a, l)))
+	content, toc := s.renderArticle(a, s.wikiLinks(w, body, a.path), l)
+	html = append(append(html, '\n'), content...)
+	html = append(html, backlinksHTML(w.backlinks[a.path], l)...)
+	return
+}
+
+// photosHandler generate web page with photos
+func (s *Server) photosHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try open page in photos: %v. %v", r.URL.Path, err)
+			}
+		}()
+		// get title, empty title is root album
+		photos := s.opts.Photos
+		title := strings.TrimPrefix(r.URL.Path, "/"+photos)
+		// Unescape url
+		title, err = url.QueryUnescape(title)
+		if err != nil {
+			err = errorf(http.StatusBadRequest, "Cannot unescape : %v", err)
+			return
+		}
+
+		// find file inside photos folder
+		f, info, err := s.resolve(photos, title)
+		if err != nil {
+			return
+		}
+		title = strings.TrimPrefix(strings.TrimPrefix(f, photos), "/")
+
+		if info.IsDir() {
+			// album with nested albums
+			var a album
+			if a, err = s.readAlbum(title); err != nil {
+				err = fileError(err, "readdir :`%s`", f)
+				return
+			}
+			l := s.links()
+			html := s.render([]byte(s.albumPage(a, l)))
+			var out []byte
+			if out, err = s.page(albumPageData(a, html, l)); err != nil {
+				return
+			}
+			w.Write(out)
+		} else {
+			// view file
+			if !isAsset(f) {
+				err = errorf(http.StatusForbidden, "File `%s` is not allowed asset", f)
+				return
+			}
+			http.ServeFile(w, r, s.file(f))
+		}
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
@@ -0,0 +1,235 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestServer return server of repository folder with options
+func newTestServer(t *testing.T, opts Options) *Server {
+	if opts.Root == "" {
+		opts.Root = ".."
+	}
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	tcs := []struct {
+		handler        func(w http.ResponseWriter, r *http.Request)
+		url            string
+		expectFilename string
+		status         int
+	}{
+		{
+			handler:        s.mainHandler,
+			url:            "/",
+			expectFilename: "test.main-index",
+		},
+		{
+			handler:        s.mainHandler,
+			url:            "/favicon.ico",
+			expectFilename: "test.main-not-found",
+			status:         http.StatusNotFound,
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/",
+			expectFilename: "test.article-empty",
+			status:         http.StatusBadRequest,
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/README.md",
+			expectFilename: "test.article-README",
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article//////////////file_not_exist.md",
+			expectFilename: "test.article-file-not-exist-md",
+			status:         http.StatusNotFound,
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/blog/testdata/frontmatter.md",
+			expectFilename: "test.article-frontmatter",
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/blog/testdata/wiki.md",
+			expectFilename: "test.article-wiki",
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/LICENSE",
+			expectFilename: "test.article-LICENSE",
+			status:         http.StatusForbidden,
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/not_exist_file",
+			expectFilename: "test.article-not-exist-file",
+			status:         http.StatusNotFound,
+		},
+		{
+			handler:        s.articleHandler,
+			url:            "/article/blog/testdata/draft.md",
+			expectFilename: "test.article-draft",
+			status:         http.StatusNotFound,
+		},
+		{
+			handler:        s.searchHandler,
+			url:            "/search?q=%22front+matter%22+article",
+			expectFilename: "test.search",
+		},
+		{
+			handler:        s.tagsHandler,
+			url:            "/tags/",
+			expectFilename: "test.tags",
+		},
+		{
+			handler:        s.tagsHandler,
+			url:            "/tags/Markdown",
+			expectFilename: "test.tags-markdown",
+		},
+		{
+			handler:        s.tagsHandler,
+			url:            "/tags/not_exist_tag",
+			expectFilename: "test.tags-not-exist",
+			status:         http.StatusNotFound,
+		},
+		{
+			handler:        s.tagsHandler,
+			url:            "/tags/100%25",
+			expectFilename: "test.tags-percent",
+			status:         http.StatusNotFound,
+		},
+	}
+
+	// modify expect filename
+	for i := range tcs {
+		if tcs[i].status == 0 {
+			tcs[i].status = http.StatusOK
+		}
+		tcs[i].expectFilename = "testdata" +
+			string(os.PathSeparator) +
+			tcs[i].expectFilename
+	}
+
+	for i := range tcs {
+		t.Run(tcs[i].url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tcs[i].url, nil)
+			w := httptest.NewRecorder()
+			tcs[i].handler(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tcs[i].status {
+				t.Errorf("not valid status code: %d != %d", resp.StatusCode, tcs[i].status)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body = bytes.Replace(body, []byte("%2F"), []byte("/"), -1)
+			body = bytes.Replace(body, []byte("+"), []byte(" "), -1)
+
+			if os.Getenv("UPDATE") == "true" {
+				err = ioutil.WriteFile(tcs[i].expectFilename, body, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			content, err := ioutil.ReadFile(tcs[i].expectFilename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body = bytes.Replace(body, []byte("\r"), []byte(""), -1)
+			content = bytes.Replace(content, []byte("\r"), []byte(""), -1)
+
+			if !bytes.Equal(body, content) {
+				text := ShowDiff(string(content), string(body))
+				t.Errorf("%s", text)
+			}
+		})
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	for _, show := range []bool{false, true} {
+		t.Run(fmt.Sprintf("%v", show), func(t *testing.T) {
+			s := newTestServer(t, Options{Drafts: show})
+			defer s.Close()
+
+			// main page
+			w := httptest.NewRecorder()
+			s.mainHandler(w, httptest.NewRequest("GET", "/", nil))
+			if found := strings.Contains(w.Body.String(), "Work in progress"); found != show {
+				t.Errorf("draft in main page: %v", found)
+			}
+
+			// article
+			w = httptest.NewRecorder()
+			s.articleHandler(w, httptest.NewRequest("GET", "/article/blog/testdata/draft.md", nil))
+			if show != (w.Code == http.StatusOK) {
+				t.Errorf("not valid status code: %d", w.Code)
+			}
+			if found := strings.Contains(w.Body.String(), "Draft article"); found != show {
+				t.Errorf("draft article is shown: %v", found)
+			}
+		})
+	}
+}
+
+// ShowDiff will print two strings vertically next to each other so that line
+// differences are easier to read.
+func ShowDiff(a, b string) string {
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+	maxLines := int(math.Max(float64(len(aLines)), float64(len(bLines))))
+	out := "\n"
+
+	for lineNumber := 0; lineNumber < maxLines; lineNumber++ {
+		aLine := ""
+		bLine := ""
+
+		// Replace NULL characters with a dot. Otherwise the strings will look
+		// exactly the same but have different length (and therfore not be
+		// equal).
+		if lineNumber < len(aLines) {
+			aLine = strconv.Quote(aLines[lineNumber])
+		}
+		if lineNumber < len(bLines) {
+			bLine = strconv.Quote(bLines[lineNumber])
+		}
+
+		diffFlag := " "
+		if aLine != bLine {
+			diffFlag = "*"
+		}
+		out += fmt.Sprintf("%s %3d %-40s%s\n", diffFlag, lineNumber+1, aLine, bLine)
+
+		if lineNumber > len(aLines) || lineNumber > len(bLines) {
+			out += "and more other ..."
+			break
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,288 @@
+package blog
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// staticLinks is links of pages generated for static hosting.
+// All links are relative, so output works from any file server.
+type staticLinks struct {
+	// root is relative path from current page to root of output folder
+	root string
+	// photos is name of photos folder
+	photos string
+}
+
+// newStaticLinks return links for page located in output folder
+func newStaticLinks(page, photos string) staticLinks {
+	page = filepath.ToSlash(filepath.Clean(page))
+	count := strings.Count(page, "/")
+	return staticLinks{root: strings.Repeat("../", count), photos: photos}
+}
+
+// staticLinks return links for page of server located in output folder
+func (s *Server) staticLinks(page string) staticLinks {
+	return newStaticLinks(page, s.opts.Photos)
+}
+
+// escape return escaped relative link
+func (s staticLinks) escape(link string) string {
+	return s.root + (&url.URL{Path: link}).String()
+}
+
+func (s staticLinks) main() string { return s.escape("index.html") }
+
+func (s staticLinks) article(p string) string {
+	return s.escape(path.Join("articles", staticName(p)))
+}
+
+func (s staticLinks) album(name string) string {
+	return s.escape(path.Join(s.photos, name, "index.html"))
+}
+
+func (s staticLinks) photo(album, name string) string {
+	return s.escape(path.Join(s.photos, album, name))
+}
+
+func (s staticLinks) thumb(album, name string) string {
+	return s.escape(path.Join(thumbs, album, name))
+}
+
+func (s staticLinks) view(album, name string) string {
+	return s.escape(path.Join(views, album, name+".html"))
+}
+
+func (s staticLinks) tag(name string) string {
+	if name = tagName(name); name == "" {
+		return s.escape(path.Join("tags", "index.html"))
+	}
+	return s.escape(path.Join("tags", name+".html"))
+}
+
+// search is not supported by static site
+func (s staticLinks) search() string { return "" }
+
+// staticName return name of file in output folder.
+// Markdown files are converted to html pages.
+func staticName(p string) string {
+	p = path.Clean(filepath.ToSlash(p))
+	if strings.HasSuffix(p, ".md") {
+		p = strings.TrimSuffix(p, ".md") + ".html"
+	}
+	return p
+}
+
+// Build generate static site in output folder
+func (s *Server) Build(output string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot build static site in `%s`: %v", output, err)
+		}
+	}()
+
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return
+	}
+
+	// main page
+	l := s.staticLinks("index.html")
+	mainTmpl, err := s.mainPage(l)
+	if err != nil {
+		return
+	}
+	if err = s.writePage(filepath.Join(output, "index.html"), mainTmpl,
+		newPage("index", s.opts.Title, nil, l)); err != nil {
+		return
+	}
+
+	// index of articles for wiki links
+	w, err := s.cache.wiki()
+	if err != nil {
+		return
+	}
+
+	// articles and assets
+	folders, err := s.getFolders(".")
+	if err != nil {
+		return
+	}
+	folders = append(folders, ".")
+	for _, folder := range folders {
+		if abs, err := filepath.Abs(s.file(folder)); err == nil &&
+			(abs == output || strings.HasPrefix(abs, output+string(filepath.Separator))) {
+			// ignore output folder
+			continue
+		}
+		var files []os.FileInfo
+		files, err = ioutil.ReadDir(s.file(folder))
+		if err != nil {
+			return
+		}
+		for _, file := range files {
+			if !file.Mode().IsRegular() || isHidden(file.Name()) {
+				continue
+			}
+			source := filepath.Join(folder, file.Name())
+			target := filepath.Join(output, "articles", filepath.FromSlash(staticName(source)))
+			if !strings.HasSuffix(file.Name(), ".md") {
+				if !isAsset(file.Name()) {
+					continue
+				}
+				if err = copyFile(target, s.file(source)); err != nil {
+					return
+				}
+				continue
+			}
+			// path of article is the same as in index
+			var e *cacheEntry
+			e, err = s.cache.get(filepath.ToSlash(folder + string(os.PathSeparator) + file.Name()))
+			if err == nil {
+				err = e.err
+			}
+			if err != nil {
+				return
+			}
+			a := e.article
+			if a.isDraft() && !s.opts.Drafts {
+				continue
+			}
+			page := filepath.Join("articles", filepath.FromSlash(staticName(source)))
+			l := s.staticLinks(page)
+			data := newPage("article", a.name, nil, l)
+			data.Meta = a.meta
+			html, toc := s.articleHTML(a, e.body, w, l)
+			data.Content, data.TOC = template.HTML(html), template.HTML(toc)
+			if err = s.writeHTML(target, data); err != nil {
+				return
+			}
+		}
+	}
+
+	// tags
+	ts, err := tags(s.cache, s.opts.Drafts)
+	if err != nil {
+		return
+	}
+	page := filepath.Join("tags", "index.html")
+	l = s.staticLinks(page)
+	if err = s.writePage(filepath.Join(output, page), tagsPage(ts, l), newPage("tags", "Tags", nil, l)); err != nil {
+		return
+	}
+	for name, articles := range ts {
+		page := filepath.Join("tags", name+".html")
+		l := s.staticLinks(page)
+		str := tagPage(name, articles, l)
+		if err = s.writePage(filepath.Join(output, page), str, newPage("tags", "Tag: "+name, nil, l)); err != nil {
+			return
+		}
+	}
+
+	// photos folder is optional
+	if _, err = os.Stat(s.file(s.opts.Photos)); err != nil {
+		return nil
+	}
+	return s.buildAlbum(output, "")
+}
+
+// buildAlbum write pages, photos and thumbnails of album and
+// all nested albums
+func (s *Server) buildAlbum(output, albumPath string) (err error) {
+	a, err := s.readAlbum(albumPath)
+	if err != nil {
+		return
+	}
+	folder := filepath.Join(s.opts.Photos, filepath.FromSlash(albumPath))
+	page := filepath.Join(folder, "index.html")
+	l := s.staticLinks(page)
+	if err = s.writePage(filepath.Join(output, page), s.albumPage(a, l), albumPageData(a, nil, l)); err != nil {
+		return
+	}
+	for i, p := range a.photos {
+		name := filepath.Join(folder, p.name)
+		if err = copyFile(filepath.Join(output, name), s.file(name)); err != nil {
+			return
+		}
+		view := filepath.Join(views, filepath.FromSlash(albumPath), p.name+".html")
+		lv := s.staticLinks(view)
+		if err = s.writePage(filepath.Join(output, view), viewPage(a, i, lv), viewPageData(a, i, nil, lv)); err != nil {
+			return
+		}
+		if !hasThumb(name) {
+			continue
+		}
+		thumb := filepath.Join(output, thumbs, filepath.FromSlash(albumPath), p.name)
+		if err = s.writeThumb(thumb, s.file(name)); err != nil {
+			return
+		}
+	}
+	for _, sub := range a.albums {
+		if err = s.buildAlbum(output, path.Join(albumPath, sub)); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// writePage convert markdown to html page and write in file
+func (s *Server) writePage(filename, markdown string, data pageData) error {
+	data.Content = template.HTML(s.render([]byte(markdown)))
+	return s.writeHTML(filename, data)
+}
+
+// writeHTML write html page in file. Static pages have no live reload.
+func (s *Server) writeHTML(filename string, data pageData) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	out, err := s.themePage(data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, out, 0644)
+}
+
+// writeThumb write thumbnail of image in file. Original image is
+// copied if that cannot be decoded and error is logged.
+func (s *Server) writeThumb(target, source string) error {
+	data, _, err := thumbnail(source)
+	if err != nil {
+		s.opts.ErrorLog.Printf("Cannot create thumbnail, image is copied : %v", err)
+		return copyFile(target, source)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(target, data, 0644)
+}
+
+// copyFile copy file from source to target
+func copyFile(target, source string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	out, err := os.Create(target)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if errC := out.Close(); errC != nil && err == nil {
+			err = errC
+		}
+	}()
+	_, err = io.Copy(out, in)
+	return
+}
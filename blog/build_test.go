@@ -0,0 +1,176 @@
+package blog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createTree create files in folder
+func createTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"src/a.md": "---\ntags: [Go]\n---\n# A\n\n![img](img.png)\n\n" +
+			"[B](/articles/.%2Fsub+folder%2Fb.md) [album](/photos/album) ![one](/photos/album/one.jpg)\n",
+		"src/img.png":                "png",
+		"src/sub folder/b.md":        "# B\n",
+		"src/photos/album/one.jpg":   "jpg",
+		"src/photos/album/README.md": "# Album\n\nDescription of album\n",
+	})
+	root := filepath.Join(dir, "src")
+	var logs bytes.Buffer
+	s := newTestServer(t, Options{Root: root, ErrorLog: log.New(&logs, "", 0)})
+	defer s.Close()
+
+	// output folder inside of tree must be ignored
+	if err := s.Build(filepath.Join(root, "public")); err != nil {
+		t.Fatal(err)
+	}
+	// second build must not see generated files
+	if err := s.Build(filepath.Join(root, "public")); err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		filename string
+		contains []string
+	}{
+		{
+			filename: "public/index.html",
+			contains: []string{
+				`href="articles/a.html"`,
+				`href="articles/sub%20folder/b.html"`,
+				`href="photos/album/index.html"`,
+			},
+		},
+		{
+			filename: "public/articles/a.html",
+			contains: []string{
+				`href="../index.html"`,
+				`src="../articles/img.png"`,
+				`href="../tags/go.html"`,
+				`href="../articles/sub%20folder/b.html"`,
+				`href="../photos/album/index.html"`,
+				`src="../photos/album/one.jpg"`,
+			},
+		},
+		{
+			filename: "public/tags/index.html",
+			contains: []string{`href="../index.html"`, `href="../tags/go.html"`},
+		},
+		{
+			filename: "public/tags/go.html",
+			contains: []string{`href="../tags/index.html"`, `href="../articles/a.html"`},
+		},
+		{
+			filename: "public/articles/sub folder/b.html",
+			contains: []string{`href="../../index.html"`, `<h1 id="b">B</h1>`},
+		},
+		{
+			filename: "public/articles/img.png",
+			contains: []string{"png"},
+		},
+		{
+			filename: "public/photos/album/index.html",
+			contains: []string{
+				`href="../../index.html"`,
+				`href="../../view/album/one.jpg.html"`,
+				`src="../../thumbs/album/one.jpg"`,
+			},
+		},
+		{
+			filename: "public/photos/album/one.jpg",
+			contains: []string{"jpg"},
+		},
+		{
+			// not valid image is copied as thumbnail
+			filename: "public/thumbs/album/one.jpg",
+			contains: []string{"jpg"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.filename, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(tc.filename)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tc.contains {
+				if !strings.Contains(string(content), c) {
+					t.Errorf("cannot find `%s` in:\n%s", c, string(content))
+				}
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "public", "articles", "public")); err == nil {
+		t.Errorf("output folder is copied into itself")
+	}
+	if !strings.Contains(logs.String(), "Cannot create thumbnail, image is copied") {
+		t.Errorf("not valid image is not logged: %s", logs.String())
+	}
+	// photos are copied only into photos folder
+	if _, err := os.Stat(filepath.Join(root, "public", "articles", "photos")); err == nil {
+		t.Errorf("photos are copied as articles")
+	}
+	content, err := ioutil.ReadFile(filepath.Join(root, "public", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "README") || strings.Contains(string(content), "Album") {
+		t.Errorf("description of album is article:\n%s", string(content))
+	}
+}
+
+func TestBuildLiveReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{"a.md": "# A\n"})
+	s := newTestServer(t, Options{Root: dir, LiveReload: true})
+	defer s.Close()
+
+	w := httptest.NewRecorder()
+	s.articleHandler(w, httptest.NewRequest("GET", "/articles/a.md", nil))
+	if !strings.Contains(w.Body.String(), reload) {
+		t.Errorf("page of server is not reloaded:\n%s", w.Body.String())
+	}
+
+	output := filepath.Join(dir, "public")
+	if err := s.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"index.html", filepath.Join("articles", "a.html")} {
+		content, err := ioutil.ReadFile(filepath.Join(output, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(content), reload) {
+			t.Errorf("static page `%s` is reloaded:\n%s", name, string(content))
+		}
+	}
+}
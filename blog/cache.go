@@ -0,0 +1,313 @@
+package blog
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// folder is folder with articles
+type folder struct {
+	// path is path to folder
+	path string
+	// articles is sorted list of articles in folder
+	articles []article
+}
+
+// cacheEntry is parsed and rendered article
+type cacheEntry struct {
+	modTime time.Time
+	size    int64
+
+	article article
+	// body is markdown of article without front matter
+	body []byte
+	// html is rendered article for web server
+	html []byte
+	// toc is rendered table of contents of article
+	toc []byte
+	// err is error of article reading
+	err error
+	// rendered is true if html is rendered for generation of cache,
+	// because wiki links and backlinks depend on other articles
+	rendered   bool
+	generation int
+}
+
+// cache is in-memory index of articles with rendered html.
+// Entries are checked by modification time and size of file.
+// List of folders is scanned once and rescanned after invalidate,
+// which is called by watcher or after rescan interval with changed
+// articles.
+// Articles are identified by path inside root folder of server.
+type cache struct {
+	s *Server
+
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+	folders []folder
+	scanned bool
+	// files is state of articles in scanned folders
+	files map[string]fileState
+	// searchIdx is inverted index of articles for search
+	searchIdx *searchIndex
+	// wikiIdx is index of articles for wiki links
+	wikiIdx *wiki
+	// generation is incremented by each invalidation
+	generation int
+	// updated is time of last check of articles
+	updated time.Time
+}
+
+// newCache return cache of articles of server
+func newCache(s *Server) *cache {
+	return &cache{
+		s:       s,
+		entries: map[string]*cacheEntry{},
+		updated: time.Now(),
+	}
+}
+
+// expire rescan folders after rescan interval, if changes of files
+// are not watched. Cache is invalidated only if articles are added,
+// removed or changed.
+func (c *cache) expire() {
+	if c.s.watcher != nil {
+		return
+	}
+	c.mu.Lock()
+	if time.Since(c.updated) <= c.s.opts.RescanInterval {
+		c.mu.Unlock()
+		return
+	}
+	c.updated = time.Now()
+	files := c.files
+	c.mu.Unlock()
+	if files == nil {
+		// folders are not scanned yet
+		return
+	}
+
+	if _, state, err := c.scan(); err != nil || isChanged(files, state) {
+		c.invalidate()
+	}
+}
+
+// get return actual cache entry of markdown file
+func (c *cache) get(path string) (e *cacheEntry, err error) {
+	info, err := os.Stat(c.s.file(path))
+	if err != nil {
+		return
+	}
+
+	c.mu.RLock()
+	e, ok := c.entries[path]
+	c.mu.RUnlock()
+	if ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e, nil
+	}
+
+	// parse article
+	e = &cacheEntry{modTime: info.ModTime(), size: info.Size()}
+	e.article, e.body, e.err = readArticle(path, c.s.file(path))
+
+	c.mu.Lock()
+	c.entries[path] = e
+	c.mu.Unlock()
+	return e, nil
+}
+
+// article return cache entry of article with rendered html and
+// error of article reading
+func (c *cache) article(path string) (e *cacheEntry, err error) {
+	if e, err = c.get(path); err != nil {
+		return
+	}
+	if e.err != nil {
+		return e, e.err
+	}
+	c.expire()
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+	if e.rendered && e.generation == generation {
+		return
+	}
+
+	w, err := c.wiki()
+	if err != nil {
+		return
+	}
+	r := *e
+	r.html, r.toc = c.s.articleHTML(r.article, r.body, w, c.s.links())
+	r.rendered, r.generation = true, generation
+
+	c.mu.Lock()
+	if c.entries[path] == e {
+		c.entries[path] = &r
+	}
+	c.mu.Unlock()
+	return &r, nil
+}
+
+// list return all folders with articles sorted by path
+func (c *cache) list() (fs []folder, err error) {
+	c.expire()
+	c.mu.RLock()
+	fs, scanned, generation := c.folders, c.scanned, c.generation
+	c.mu.RUnlock()
+	if scanned {
+		return
+	}
+
+	fs, state, err := c.scan()
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if generation == c.generation {
+		// files are not changed during scanning
+		c.folders, c.files, c.scanned = fs, state, true
+	}
+	c.mu.Unlock()
+	return
+}
+
+// search return inverted index of all articles
+func (c *cache) search() (s *searchIndex, err error) {
+	c.expire()
+	c.mu.RLock()
+	s, generation := c.searchIdx, c.generation
+	c.mu.RUnlock()
+	if s != nil {
+		return
+	}
+
+	s, err = newSearchIndex(c)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if generation == c.generation {
+		// files are not changed during indexing
+		c.searchIdx = s
+	}
+	c.mu.Unlock()
+	return
+}
+
+// wiki return index of all articles for wiki links
+func (c *cache) wiki() (w *wiki, err error) {
+	c.expire()
+	c.mu.RLock()
+	w, generation := c.wikiIdx, c.generation
+	c.mu.RUnlock()
+	if w != nil {
+		return
+	}
+
+	w, err = newWiki(c, c.s.opts.Drafts)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if generation == c.generation {
+		// files are not changed during indexing
+		c.wikiIdx = w
+	}
+	c.mu.Unlock()
+	return
+}
+
+// scan return all folders with articles and state of articles
+func (c *cache) scan() (fs []folder, state map[string]fileState, err error) {
+	state = map[string]fileState{}
+	// get all folders
+	folders, err := c.s.getFolders(".")
+	if err != nil {
+		return
+	}
+	folders = append(folders, ".")
+	sort.Strings(folders)
+
+	// find all markdown files
+	for i := range folders {
+		var files []os.FileInfo
+		files, err = ioutil.ReadDir(c.s.file(folders[i]))
+		if err != nil {
+			return
+		}
+		f := folder{path: folders[i]}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			if !strings.HasSuffix(file.Name(), ".md") {
+				continue
+			}
+			path := folders[i] + string(os.PathSeparator) + file.Name()
+
+			// Windows specific
+			if runtime.GOOS == windowsOs {
+				path = strings.Replace(path, "\\", "/", -1)
+			}
+
+			// hidden files and symlinks outside of root folder
+			if _, _, errR := c.s.resolve(".", path); errR != nil {
+				continue
+			}
+
+			a := article{path: path, name: path}
+			if e, errE := c.get(path); errE == nil {
+				state[path] = fileState{modTime: e.modTime, size: e.size}
+				if e.err == nil {
+					a = e.article
+				}
+			}
+			f.articles = append(f.articles, a)
+		}
+		if len(f.articles) == 0 {
+			continue
+		}
+		sortArticles(f.articles)
+		fs = append(fs, f)
+	}
+	return
+}
+
+// invalidate remove entries of not exist files and
+// prepare list of folders for rescanning
+func (c *cache) invalidate() {
+	c.mu.Lock()
+	c.scanned = false
+	c.folders = nil
+	c.files = nil
+	c.searchIdx = nil
+	c.wikiIdx = nil
+	c.generation++
+	c.updated = time.Now()
+	entries := make(map[string]*cacheEntry, len(c.entries))
+	for path, e := range c.entries {
+		entries[path] = e
+	}
+	c.mu.Unlock()
+
+	// files are checked without lock of readers
+	for path, e := range entries {
+		if _, err := os.Stat(c.s.file(path)); err == nil {
+			continue
+		}
+		c.mu.Lock()
+		if c.entries[path] == e {
+			delete(c.entries, path)
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,136 @@
+package blog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"a.md":     "# A\n",
+		"sub/b.md": "---\ntitle: B\n---\ntext\n",
+		"sub/c.go": "package c",
+	})
+
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+	c := newCache(s)
+
+	names := func() string {
+		t.Helper()
+		fs, err := c.list()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range fs {
+			for _, a := range f.articles {
+				names = append(names, a.name)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+
+	if n := names(); n != "A,B" {
+		t.Fatalf("not valid articles: %s", n)
+	}
+
+	// modify article
+	path := "./a.md"
+	createTree(t, dir, map[string]string{"a.md": "# A modified\n"})
+	e, err := c.article(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.article.name != "A modified" || !strings.Contains(string(e.html), "A modified") {
+		t.Errorf("article is not updated: %s\n%s", e.article.name, string(e.html))
+	}
+
+	// new article is visible only after invalidation
+	createTree(t, dir, map[string]string{"sub/d.md": "# D\n"})
+	if n := names(); n != "A,B" {
+		t.Fatalf("list is rescanned: %s", n)
+	}
+	c.invalidate()
+	if n := names(); n != "A modified,B,D" {
+		t.Fatalf("not valid articles: %s", n)
+	}
+
+	// remove article
+	if err := os.Remove(filepath.Join(dir, "a.md")); err != nil {
+		t.Fatal(err)
+	}
+	c.invalidate()
+	if _, ok := c.entries[path]; ok {
+		t.Errorf("entry of removed file is not deleted")
+	}
+	if n := names(); n != "B,D" {
+		t.Fatalf("not valid articles: %s", n)
+	}
+	if _, err := c.article(path); err == nil {
+		t.Errorf("removed article is found")
+	}
+
+	// list is rescanned after interval without live reload
+	if s.watcher != nil {
+		t.Errorf("files are watched without live reload")
+	}
+	s.opts.RescanInterval = time.Nanosecond
+	createTree(t, dir, map[string]string{"e.md": "# E\n"})
+	if n := names(); n != "E,B,D" {
+		t.Fatalf("list is not rescanned: %s", n)
+	}
+
+	// articles are not rendered again after interval without changes
+	e, err = c.article("./e.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generation := c.generation
+	names()
+	if again, err := c.article("./e.md"); err != nil || again != e || c.generation != generation {
+		t.Errorf("cache is invalidated without changes: %d != %d", c.generation, generation)
+	}
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{"a.md": "# A\n"})
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+	c := newCache(s)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := c.list(); err != nil {
+					t.Error(err)
+				}
+				if _, err := c.article("a.md"); err != nil {
+					t.Error(err)
+				}
+				c.invalidate()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,239 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// Problem is broken internal link, image or heading anchor of article
+type Problem struct {
+	// File is path of article inside root folder
+	File string
+	// Line is line of link in file. Zero is unknown line.
+	Line int
+	// Message is description of problem
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// checker check links of articles
+type checker struct {
+	s *Server
+	w *wiki
+	// ids is heading identifiers by path of article
+	ids map[string]map[string]bool
+}
+
+// Check return problems of internal links, images and heading anchors
+// of all articles including drafts. Problems are sorted by location.
+func (s *Server) Check() (ps []Problem, err error) {
+	folders, err := s.cache.list()
+	if err != nil {
+		return
+	}
+	w, err := newWiki(s.cache, true)
+	if err != nil {
+		return
+	}
+	c := &checker{s: s, w: w, ids: map[string]map[string]bool{}}
+	for _, f := range folders {
+		for _, a := range f.articles {
+			var aps []Problem
+			if aps, err = c.article(a.path); err != nil {
+				return
+			}
+			ps = append(ps, aps...)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].File != ps[j].File {
+			return ps[i].File < ps[j].File
+		}
+		return ps[i].Line < ps[j].Line
+	})
+	return
+}
+
+// locator return lines of texts in order of appearance in markdown
+type locator struct {
+	text string
+	// first is line of first line of text in file
+	first  int
+	offset int
+}
+
+// line return line of next appearance of text in file
+func (l *locator) line(text string) int {
+	index := strings.Index(l.text[l.offset:], text)
+	if 0 <= index {
+		index += l.offset
+	} else if index = strings.Index(l.text, text); index < 0 {
+		return 0
+	}
+	l.offset = index + len(text)
+	return l.first + strings.Count(l.text[:index], "\n")
+}
+
+// article return problems of links in article
+func (c *checker) article(p string) (ps []Problem, err error) {
+	file := strings.TrimPrefix(p, "./")
+	e, err := c.s.cache.get(p)
+	if err != nil {
+		return
+	}
+	if e.err != nil {
+		return []Problem{{File: file, Message: e.err.Error()}}, nil
+	}
+	content, err := ioutil.ReadFile(c.s.file(p))
+	if err != nil {
+		return
+	}
+	// lines of front matter are located before body
+	content = bytes.Replace(content, []byte("\r"), []byte(""), -1)
+	first := 1 + bytes.Count(content, []byte("\n")) - bytes.Count(e.body, []byte("\n"))
+
+	ast := c.s.parseMarkdown(e.body)
+	ids := c.headings(p)
+
+	loc := &locator{text: string(e.body), first: first}
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || (node.Type != blackfriday.Link && node.Type != blackfriday.Image) ||
+			node.LinkData.NoteID != 0 {
+			return blackfriday.GoToNext
+		}
+		dest := string(node.LinkData.Destination)
+		if msg := c.link(p, dest, ids); msg != "" {
+			kind := "link"
+			if node.Type == blackfriday.Image {
+				kind = "image"
+			}
+			ps = append(ps, Problem{
+				File:    file,
+				Line:    loc.line(dest),
+				Message: fmt.Sprintf("%s `%s`: %s", kind, dest, msg),
+			})
+		}
+		return blackfriday.GoToNext
+	})
+
+	loc = &locator{text: string(e.body), first: first}
+	for _, target := range c.s.wikiTargets(e.body) {
+		line := loc.line(target)
+		var msg string
+		a, anchor, ok := c.w.find(target)
+		switch {
+		case strings.HasPrefix(target, "#"):
+			if !ids[anchor] {
+				msg = fmt.Sprintf("heading `#%s` is not found", anchor)
+			}
+		case !ok:
+			msg = "article is not found"
+		case anchor != "" && !c.headings(a.path)[anchor]:
+			msg = fmt.Sprintf("heading `#%s` is not found in `%s`", anchor, strings.TrimPrefix(a.path, "./"))
+		}
+		if msg != "" {
+			ps = append(ps, Problem{
+				File:    file,
+				Line:    line,
+				Message: fmt.Sprintf("wiki link `[[%s]]`: %s", target, msg),
+			})
+		}
+	}
+	return
+}
+
+// headings return identifiers of headings of article
+func (c *checker) headings(p string) map[string]bool {
+	if ids, ok := c.ids[p]; ok {
+		return ids
+	}
+	ids := map[string]bool{}
+	if e, err := c.s.cache.get(p); err == nil && e.err == nil {
+		body := e.body
+		if !c.s.opts.NoMath {
+			// identifiers of headings with formulas are same as rendered
+			body, _ = c.s.extractMath(body)
+		}
+		for _, h := range headings(c.s.parseMarkdown(body)) {
+			ids[h.id] = true
+		}
+	}
+	c.ids[p] = ids
+	return ids
+}
+
+// link return problem of link from article or empty string for valid
+// link. External links are not checked.
+func (c *checker) link(p, dest string, ids map[string]bool) string {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return "not valid address"
+	}
+	if u.Scheme != "" || u.Host != "" || u.Opaque != "" {
+		return ""
+	}
+	if u.Path == "" {
+		if u.Fragment != "" && !ids[u.Fragment] {
+			return fmt.Sprintf("heading `#%s` is not found", u.Fragment)
+		}
+		return ""
+	}
+
+	// location of file inside root folder
+	root, name := ".", u.Path
+	switch photos := "/" + c.s.opts.Photos + "/"; {
+	case strings.HasPrefix(u.Path, "/articles/"):
+		if name, err = url.QueryUnescape(strings.TrimPrefix(u.EscapedPath(), "/articles/")); err != nil {
+			return "not valid address"
+		}
+	case strings.HasPrefix(u.Path, photos):
+		root, name = c.s.opts.Photos, strings.TrimPrefix(u.Path, photos)
+	case strings.HasPrefix(u.Path, "/"):
+		// other pages of server
+		return ""
+	default:
+		name = path.Join(path.Dir(p), u.Path)
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return "file is outside of root folder"
+		}
+	}
+
+	f, info, err := c.s.resolve(root, name)
+	if err != nil {
+		if statusCode(err) == http.StatusNotFound {
+			return "file is not found"
+		}
+		return err.Error()
+	}
+	if info.IsDir() {
+		if root == "." {
+			return "folder cannot be opened"
+		}
+		// album of photos
+		return ""
+	}
+	if !strings.HasSuffix(f, ".md") {
+		if !isAsset(f) {
+			return "file is not allowed asset"
+		}
+		return ""
+	}
+	if u.Fragment != "" && !c.headings(f)[u.Fragment] {
+		return fmt.Sprintf("heading `#%s` is not found in `%s`", u.Fragment, strings.TrimPrefix(f, "./"))
+	}
+	return ""
+}
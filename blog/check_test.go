@@ -0,0 +1,73 @@
+package blog
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"a.md": strings.Join([]string{
+			"---",
+			"title: A",
+			"---",
+			"# A",
+			"",
+			"## Part",
+			"",
+			"[ok](sub/b.md) [ok](sub/b.md#b) [ok](#part) [ok](img.png)",
+			"[ok](https://example.com/x.md) [ok](mailto:a@example.com) [ok](/tags/)",
+			"[ok](/articles/.%2Fsub%2Fb.md) [ok](/photos/album) [[B]] [[sub/b#B]]",
+			"",
+			"[missing](sub/c.md)",
+			"![missing image](no.png)",
+			"[anchor](#absent) [anchor](sub/b.md#absent)",
+			"[outside](../x.md) [not asset](main.go) [folder](sub)",
+			"[[Absent]] [[B#absent]]",
+			"",
+			"`[[Absent]]` [ok][1]",
+			"",
+			"    [[Absent]]",
+			"",
+			"[1]: sub/b.md",
+		}, "\n"),
+		"img.png":             "png",
+		"main.go":             "package main",
+		"sub/b.md":            "# B\n\n[up](../a.md#part) ![photo](/photos/album/no.jpg)\n",
+		"photos/album/ok.jpg": "jpg",
+	})
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	ps, err := s.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, p := range ps {
+		out = append(out, p.String())
+	}
+	expect := []string{
+		"a.md:12: link `sub/c.md`: file is not found",
+		"a.md:13: image `no.png`: file is not found",
+		"a.md:14: link `#absent`: heading `#absent` is not found",
+		"a.md:14: link `sub/b.md#absent`: heading `#absent` is not found in `sub/b.md`",
+		"a.md:15: link `../x.md`: file is outside of root folder",
+		"a.md:15: link `main.go`: file is not allowed asset",
+		"a.md:15: link `sub`: folder cannot be opened",
+		"a.md:16: wiki link `[[Absent]]`: article is not found",
+		"a.md:16: wiki link `[[B#absent]]`: heading `#absent` is not found in `sub/b.md`",
+		"sub/b.md:3: image `/photos/album/no.jpg`: file is not found",
+	}
+	if a, b := strings.Join(out, "\n"), strings.Join(expect, "\n"); a != b {
+		t.Errorf("not valid problems:\n%s", ShowDiff(a, b))
+	}
+}
@@ -0,0 +1,87 @@
+package blog
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"syscall"
+)
+
+// httpError is error of web page with HTTP status code
+type httpError struct {
+	// code is HTTP status code
+	code int
+	// err is detailed cause of error. That cause is logged on server side
+	// only and never sent to the client.
+	err error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+// errorf return error with HTTP status code
+func errorf(code int, format string, args ...interface{}) error {
+	return &httpError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// statusCode return HTTP status code of error.
+// Errors without status code are internal server errors.
+func statusCode(err error) int {
+	if e, ok := err.(*httpError); ok {
+		return e.code
+	}
+	return http.StatusInternalServerError
+}
+
+// fileError return error of file opening with HTTP status code
+func fileError(err error, format string, args ...interface{}) error {
+	code := http.StatusInternalServerError
+	switch {
+	case os.IsNotExist(err) || isNotDir(err):
+		code = http.StatusNotFound
+	case os.IsPermission(err):
+		code = http.StatusForbidden
+	}
+	return errorf(code, format+": %v", append(args, err)...)
+}
+
+// isNotDir return true for error of path with file instead of folder
+func isNotDir(err error) bool {
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return err == syscall.ENOTDIR
+}
+
+// errorRecorder is response writer with logging of errors
+type errorRecorder interface {
+	recordError(err error)
+}
+
+// writeError log detailed cause of error and write error page
+// with status code. Error is logged by access log if response writer
+// supports that, otherwise by error logger of server.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code := statusCode(err)
+	if rec, ok := w.(errorRecorder); ok {
+		rec.recordError(err)
+	} else {
+		s.opts.ErrorLog.Printf("Error : %d : %v", code, err)
+	}
+
+	content := fmt.Sprintf("<h1>%d %s</h1>\n\n", code, html.EscapeString(http.StatusText(code)))
+	l := s.links()
+	content += fmt.Sprintf("<p><a href=\"%s\">Main page</a></p>\n", l.main())
+
+	out, errP := s.page(newPage("error", http.StatusText(code), []byte(content), l))
+	if errP != nil {
+		s.opts.ErrorLog.Printf("Error : cannot generate error page : %v", errP)
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(out)
+}
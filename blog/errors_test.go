@@ -0,0 +1,52 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	_, errNotExist := os.Stat("not_exist_file")
+	tcs := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("some error"), http.StatusInternalServerError},
+		{errorf(http.StatusBadRequest, "bad"), http.StatusBadRequest},
+		{errorf(statusCode(errorf(http.StatusForbidden, "a")), "b"), http.StatusForbidden},
+		{fileError(errNotExist, "file"), http.StatusNotFound},
+		{fileError(&os.PathError{Op: "open", Path: "f", Err: os.ErrPermission}, "file"), http.StatusForbidden},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			if code := statusCode(tc.err); code != tc.code {
+				t.Errorf("not valid status code: %d != %d", code, tc.code)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTestServer(t, Options{ErrorLog: log.New(&buf, "", 0)})
+	defer s.Close()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/articles/secret.md", nil)
+	s.writeError(w, r, errorf(http.StatusNotFound, "Cannot read file `secret.md`"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("not valid status code: %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "secret") || !strings.Contains(body, "404 Not Found") {
+		t.Errorf("not valid error page:\n%s", body)
+	}
+	if out := buf.String(); out != "Error : 404 : Cannot read file `secret.md`\n" {
+		t.Errorf("not valid error log: %q", out)
+	}
+}
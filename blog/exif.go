@@ -0,0 +1,353 @@
+package blog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// exifDateLayout is layout of dates in EXIF
+const exifDateLayout string = "2006:01:02 15:04:05"
+
+// exif tags
+const (
+	tagMake             uint16 = 0x010F
+	tagModel            uint16 = 0x0110
+	tagOrientation      uint16 = 0x0112
+	tagDateTime         uint16 = 0x0132
+	tagExifIFD          uint16 = 0x8769
+	tagDateTimeOriginal uint16 = 0x9003
+)
+
+// exifTypeSizes is sizes of EXIF value types in bytes
+var exifTypeSizes = map[uint16]uint32{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	7:  1, // UNDEFINED
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+}
+
+// exifData is metadata of photo from EXIF
+type exifData struct {
+	// date is capture date of photo
+	date time.Time
+	// camera is make and model of camera
+	camera string
+	// orientation is value of EXIF orientation tag from 1 to 8.
+	// Zero if orientation is unknown.
+	orientation int
+}
+
+// readEXIF return metadata from APP1 segment of JPEG image.
+// Empty metadata is returned for JPEG without EXIF.
+func readEXIF(r io.Reader) (e exifData, err error) {
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err = io.ReadFull(br, soi[:]); err != nil {
+		return
+	}
+	if soi != [2]byte{0xFF, 0xD8} {
+		err = fmt.Errorf("not JPEG image")
+		return
+	}
+	for {
+		var c byte
+		if c, err = br.ReadByte(); err != nil {
+			return
+		}
+		if c != 0xFF {
+			err = fmt.Errorf("not valid JPEG marker %#x", c)
+			return
+		}
+		// skip fill bytes
+		marker := byte(0xFF)
+		for marker == 0xFF {
+			if marker, err = br.ReadByte(); err != nil {
+				return
+			}
+		}
+		switch {
+		case marker == 0x01 || (0xD0 <= marker && marker <= 0xD8):
+			// markers without length
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// image data is started, so EXIF is not exist
+			return
+		}
+		var length [2]byte
+		if _, err = io.ReadFull(br, length[:]); err != nil {
+			return
+		}
+		size := int(binary.BigEndian.Uint16(length[:])) - 2
+		if size < 0 {
+			err = fmt.Errorf("not valid size of JPEG segment")
+			return
+		}
+		if marker != 0xE1 {
+			if _, err = br.Discard(size); err != nil {
+				return
+			}
+			continue
+		}
+		data := make([]byte, size)
+		if _, err = io.ReadFull(br, data); err != nil {
+			return
+		}
+		if header := []byte("Exif\x00\x00"); bytes.HasPrefix(data, header) {
+			return parseEXIF(data[len(header):])
+		}
+	}
+}
+
+// parseEXIF return metadata from TIFF structure of EXIF
+func parseEXIF(b []byte) (e exifData, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot parse EXIF: %v", err)
+		}
+	}()
+	if len(b) < 8 {
+		err = fmt.Errorf("header is too small")
+		return
+	}
+	var order binary.ByteOrder
+	switch string(b[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		err = fmt.Errorf("not valid byte order")
+		return
+	}
+
+	// readIFD call function for each entry of image file directory
+	readIFD := func(offset uint32, fn func(tag, typ uint16, value []byte)) error {
+		if uint32(len(b)) < offset+2 || offset+2 < offset {
+			return fmt.Errorf("not valid offset of directory")
+		}
+		count := uint32(order.Uint16(b[offset:]))
+		entries := offset + 2
+		if uint32(len(b)) < entries+count*12 {
+			return fmt.Errorf("directory is out of range")
+		}
+		for i := uint32(0); i < count; i++ {
+			entry := b[entries+i*12 : entries+(i+1)*12]
+			tag, typ := order.Uint16(entry), order.Uint16(entry[2:])
+			size := exifTypeSizes[typ] * order.Uint32(entry[4:])
+			if size == 0 {
+				continue
+			}
+			value := entry[8:]
+			if 4 < size {
+				start := order.Uint32(entry[8:])
+				if uint32(len(b)) < start+size || start+size < start {
+					continue
+				}
+				value = b[start : start+size]
+			} else {
+				value = value[:size]
+			}
+			fn(tag, typ, value)
+		}
+		return nil
+	}
+
+	ascii := func(value []byte) string {
+		return strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+	}
+	var maker, model, dateTime, dateOriginal string
+	var exifIFD uint32
+	if err = readIFD(order.Uint32(b[4:]), func(tag, typ uint16, value []byte) {
+		switch tag {
+		case tagMake:
+			maker = ascii(value)
+		case tagModel:
+			model = ascii(value)
+		case tagDateTime:
+			dateTime = ascii(value)
+		case tagOrientation:
+			if typ == 3 {
+				e.orientation = int(order.Uint16(value))
+			}
+		case tagExifIFD:
+			if typ == 4 {
+				exifIFD = order.Uint32(value)
+			}
+		}
+	}); err != nil {
+		return
+	}
+	if exifIFD != 0 {
+		if err = readIFD(exifIFD, func(tag, typ uint16, value []byte) {
+			if tag == tagDateTimeOriginal {
+				dateOriginal = ascii(value)
+			}
+		}); err != nil {
+			return
+		}
+	}
+
+	if e.orientation < 1 || 8 < e.orientation {
+		e.orientation = 0
+	}
+	e.camera = model
+	if !strings.HasPrefix(model, maker) {
+		e.camera = strings.TrimSpace(maker + " " + model)
+	}
+	for _, d := range []string{dateOriginal, dateTime} {
+		if t, errT := time.Parse(exifDateLayout, d); errT == nil {
+			e.date = t
+			break
+		}
+	}
+	return
+}
+
+// orient return image rotated and flipped by EXIF orientation
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || 8 < orientation {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if 5 <= orientation {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // flip horizontal
+				sx, sy = w-1-x, y
+			case 3: // rotate 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // flip vertical
+				sx, sy = x, h-1-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transverse
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotate 270 clockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// photoInfo is file of photo album with metadata
+type photoInfo struct {
+	name string
+	exifData
+	// width and height of image as displayed after orientation
+	width, height int
+}
+
+// readPhoto return metadata of image file. Metadata is empty
+// for files which are not images.
+func readPhoto(path string) (p photoInfo, err error) {
+	p.name = filepath.Base(path)
+	if !hasThumb(path) {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if e, errE := readEXIF(f); errE == nil {
+		p.exifData = e
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	if c, _, errC := image.DecodeConfig(f); errC == nil {
+		p.width, p.height = c.Width, c.Height
+		if 5 <= p.orientation {
+			p.width, p.height = p.height, p.width
+		}
+	}
+	return
+}
+
+// photoCache is in-memory cache of metadata of photos.
+// Entries are checked by modification time and size of file.
+type photoCache struct {
+	mu      sync.Mutex
+	entries map[string]*photoEntry
+}
+
+// photoEntry is cached metadata of photo
+type photoEntry struct {
+	modTime time.Time
+	size    int64
+	photo   photoInfo
+}
+
+// get return actual metadata of file by information from folder listing
+func (c *photoCache) get(path string, info os.FileInfo) (p photoInfo, err error) {
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e.photo, nil
+	}
+
+	if p, err = readPhoto(path); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.entries[path] = &photoEntry{modTime: info.ModTime(), size: info.Size(), photo: p}
+	c.mu.Unlock()
+	return
+}
+
+// readPhotos return photos and other assets of album folder sorted by
+// capture date. Files without capture date are located at the end
+// in order of names. Metadata of photos is cached.
+func (c *photoCache) readPhotos(dir string) (ps []photoInfo, err error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if isHidden(file.Name()) || file.IsDir() || !isAsset(file.Name()) {
+			continue
+		}
+		var p photoInfo
+		if p, err = c.get(filepath.Join(dir, file.Name()), file); err != nil {
+			return
+		}
+		ps = append(ps, p)
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		di, dj := ps[i].date, ps[j].date
+		if di.IsZero() || dj.IsZero() {
+			return !di.IsZero() && dj.IsZero()
+		}
+		return di.Before(dj)
+	})
+	return
+}
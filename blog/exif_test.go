@@ -0,0 +1,303 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exifEntry is entry of image file directory for tests
+type exifEntry struct {
+	tag   uint16
+	typ   uint16
+	value interface{}
+}
+
+// tiffData return TIFF structure with two image file directories:
+// IFD0 and EXIF IFD. Pointer to EXIF IFD is added to IFD0 if EXIF IFD
+// is not empty.
+func tiffData(order binary.ByteOrder, ifd0, exifIFD []exifEntry) []byte {
+	size := func(entries []exifEntry) uint32 { return uint32(2 + 12*len(entries) + 4) }
+	if 0 < len(exifIFD) {
+		ifd0 = append(ifd0, exifEntry{tagExifIFD, 4, uint32(0)})
+	}
+	exifOffset := 8 + size(ifd0)
+	dataOffset := exifOffset + size(exifIFD)
+
+	var data bytes.Buffer
+	writeIFD := func(buf *bytes.Buffer, entries []exifEntry) {
+		binary.Write(buf, order, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(buf, order, e.tag)
+			binary.Write(buf, order, e.typ)
+			var value [4]byte
+			switch v := e.value.(type) {
+			case string:
+				s := v + "\x00"
+				binary.Write(buf, order, uint32(len(s)))
+				if len(s) <= 4 {
+					copy(value[:], s)
+				} else {
+					order.PutUint32(value[:], dataOffset+uint32(data.Len()))
+					data.WriteString(s)
+				}
+			case uint16:
+				binary.Write(buf, order, uint32(1))
+				order.PutUint16(value[:], v)
+			case uint32:
+				binary.Write(buf, order, uint32(1))
+				if e.tag == tagExifIFD {
+					v = exifOffset
+				}
+				order.PutUint32(value[:], v)
+			}
+			buf.Write(value[:])
+		}
+		binary.Write(buf, order, uint32(0))
+	}
+
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	binary.Write(&buf, order, uint16(42))
+	binary.Write(&buf, order, uint32(8))
+	writeIFD(&buf, ifd0)
+	writeIFD(&buf, exifIFD)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+// exifJPEG return JPEG image with EXIF segment
+func exifJPEG(t *testing.T, img image.Image, tiff []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(buf.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestReadEXIF(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	tcs := []struct {
+		name   string
+		order  binary.ByteOrder
+		ifd0   []exifEntry
+		exif   []exifEntry
+		expect exifData
+	}{
+		{
+			name:  "little endian",
+			order: binary.LittleEndian,
+			ifd0: []exifEntry{
+				{tagMake, 2, "Canon"},
+				{tagModel, 2, "Canon EOS 5D"},
+				{tagOrientation, 3, uint16(6)},
+				{tagDateTime, 2, "2019:05:18 10:00:00"},
+			},
+			exif: []exifEntry{
+				{tagDateTimeOriginal, 2, "2019:05:17 09:30:15"},
+			},
+			expect: exifData{
+				date:        time.Date(2019, 5, 17, 9, 30, 15, 0, time.UTC),
+				camera:      "Canon EOS 5D",
+				orientation: 6,
+			},
+		},
+		{
+			name:  "big endian",
+			order: binary.BigEndian,
+			ifd0: []exifEntry{
+				{tagMake, 2, "NIKON"},
+				{tagModel, 2, "D750"},
+				{tagOrientation, 3, uint16(3)},
+				{tagDateTime, 2, "2020:01:02 03:04:05"},
+			},
+			expect: exifData{
+				date:        time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				camera:      "NIKON D750",
+				orientation: 3,
+			},
+		},
+		{
+			name:  "not valid values",
+			order: binary.LittleEndian,
+			ifd0: []exifEntry{
+				{tagOrientation, 3, uint16(42)},
+				{tagDateTime, 2, "unknown"},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			data := exifJPEG(t, img, tiffData(tc.order, tc.ifd0, tc.exif))
+			e, err := readEXIF(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e != tc.expect {
+				t.Errorf("not valid EXIF:\n%#v\n%#v", e, tc.expect)
+			}
+			// image is still valid
+			if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+				t.Errorf("not valid image: %v", err)
+			}
+		})
+	}
+
+	// JPEG without EXIF
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := readEXIF(&buf); err != nil || e != (exifData{}) {
+		t.Errorf("not empty EXIF: %#v %v", e, err)
+	}
+
+	// not valid data
+	for _, data := range []string{"", "png", "\xFF\xD8\xFF\xE1\x00\x0AExif\x00\x00II", "\xFF\xD8\x00"} {
+		if _, err := readEXIF(strings.NewReader(data)); err == nil {
+			t.Errorf("error is not found for %q", data)
+		}
+	}
+	broken := "Exif\x00\x00II\x2A\x00\xFF\xFF\x00\x00"
+	data := "\xFF\xD8\xFF\xE1\x00" + string(rune(len(broken)+2)) + broken
+	if _, err := readEXIF(strings.NewReader(data)); err == nil {
+		t.Errorf("not valid offset of directory is accepted")
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// image 3x2 with unique colors
+	//	0 1 2
+	//	3 4 5
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i)
+	}
+	tcs := []struct {
+		orientation int
+		expect      [][]uint8
+	}{
+		{1, [][]uint8{{0, 1, 2}, {3, 4, 5}}},
+		{2, [][]uint8{{2, 1, 0}, {5, 4, 3}}},
+		{3, [][]uint8{{5, 4, 3}, {2, 1, 0}}},
+		{4, [][]uint8{{3, 4, 5}, {0, 1, 2}}},
+		{5, [][]uint8{{0, 3}, {1, 4}, {2, 5}}},
+		{6, [][]uint8{{3, 0}, {4, 1}, {5, 2}}},
+		{7, [][]uint8{{5, 2}, {4, 1}, {3, 0}}},
+		{8, [][]uint8{{2, 5}, {1, 4}, {0, 3}}},
+	}
+	for _, tc := range tcs {
+		dst := orient(src, tc.orientation)
+		for y, row := range tc.expect {
+			for x, v := range row {
+				if c := color.GrayModel.Convert(dst.At(x, y)).(color.Gray); c.Y != v {
+					t.Errorf("orientation %d: not valid pixel %d,%d: %d != %d",
+						tc.orientation, x, y, c.Y, v)
+				}
+			}
+		}
+		if size := dst.Bounds().Size(); size != image.Pt(len(tc.expect[0]), len(tc.expect)) {
+			t.Errorf("orientation %d: not valid size %v", tc.orientation, size)
+		}
+	}
+}
+
+func TestReadPhotos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-exif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	img := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	photo := func(date string, orientation uint16) string {
+		return string(exifJPEG(t, img, tiffData(binary.LittleEndian, []exifEntry{
+			{tagModel, 2, "Phone"},
+			{tagOrientation, 3, orientation},
+			{tagDateTime, 2, date},
+		}, nil)))
+	}
+	createTree(t, dir, map[string]string{
+		"a-late.jpg":  photo("2019:05:18 10:00:00", 1),
+		"b-early.jpg": photo("2019:05:17 10:00:00", 6),
+		"c.mp4":       "mp4",
+		"d.txt":       "txt",
+		".hidden.jpg": "jpg",
+		"e.go":        "package e",
+	})
+
+	c := &photoCache{entries: map[string]*photoEntry{}}
+	ps, err := c.readPhotos(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range ps {
+		names = append(names, p.name)
+	}
+	if n := strings.Join(names, ","); n != "b-early.jpg,a-late.jpg,c.mp4,d.txt" {
+		t.Fatalf("not valid order: %s", n)
+	}
+	if p := ps[0]; p.width != 300 || p.height != 600 || p.camera != "Phone" {
+		t.Errorf("not valid rotated photo: %#v", p)
+	}
+	if p := ps[1]; p.width != 600 || p.height != 300 {
+		t.Errorf("not valid photo: %#v", p)
+	}
+
+	// metadata is cached until file is changed
+	c.entries[filepath.Join(dir, "a-late.jpg")].photo.camera = "Cached"
+	if cached, err := c.readPhotos(dir); err != nil || cached[1].camera != "Cached" {
+		t.Errorf("metadata is not cached: %#v %v", cached, err)
+	}
+	createTree(t, dir, map[string]string{"a-late.jpg": photo("2019:05:16 10:00:00", 1)})
+	if changed, err := c.readPhotos(dir); err != nil || changed[0].camera != "Phone" ||
+		changed[0].name != "a-late.jpg" {
+		t.Errorf("metadata is not updated: %#v %v", changed, err)
+	}
+
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+	content := s.albumPage(album{path: "album", photos: ps}, s.links())
+	for _, expect := range []string{
+		`[![b-early.jpg](/thumbs/album/b-early.jpg "Phone, 300x600")](/view/album/b-early.jpg)`,
+		"*2019-05-17 10:00*",
+		"*2019-05-18 10:00*",
+	} {
+		if !strings.Contains(content, expect) {
+			t.Errorf("cannot find `%s` in:\n%s", expect, content)
+		}
+	}
+
+	// thumbnail is rotated
+	data, _, err := thumbnail(filepath.Join(dir, "b-early.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := thumb.Bounds().Size(); size != image.Pt(150, 300) {
+		t.Errorf("thumbnail is not rotated: %v", size)
+	}
+}
@@ -0,0 +1,262 @@
+package blog
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// feedItem is article in feed
+type feedItem struct {
+	article article
+	// link is absolute link to article
+	link string
+	// date is date from front matter or modification time of file
+	date time.Time
+	// body is markdown of article without front matter
+	body []byte
+	// html is rendered content of article without page header and
+	// backlinks with absolute links
+	html []byte
+}
+
+// absoluteLinks is links of server with absolute addresses, because
+// feed readers cannot resolve addresses relative to blog
+type absoluteLinks struct {
+	base string
+	links
+}
+
+func (l absoluteLinks) main() string { return l.base + l.links.main() }
+
+func (l absoluteLinks) article(path string) string { return l.base + l.links.article(path) }
+
+func (l absoluteLinks) album(name string) string { return l.base + l.links.album(name) }
+
+func (l absoluteLinks) photo(album, name string) string {
+	return l.base + l.links.photo(album, name)
+}
+
+func (l absoluteLinks) thumb(album, name string) string {
+	return l.base + l.links.thumb(album, name)
+}
+
+func (l absoluteLinks) view(album, name string) string {
+	return l.base + l.links.view(album, name)
+}
+
+func (l absoluteLinks) tag(name string) string { return l.base + l.links.tag(name) }
+
+func (l absoluteLinks) search() string {
+	if action := l.links.search(); action != "" {
+		return l.base + action
+	}
+	return ""
+}
+
+// feedItems return most recent articles for feed
+func (s *Server) feedItems(base string, size int) (items []feedItem, err error) {
+	folders, err := s.cache.list()
+	if err != nil {
+		return
+	}
+	w, err := s.cache.wiki()
+	if err != nil {
+		return
+	}
+	l := absoluteLinks{base: base, links: s.links()}
+	for _, f := range folders {
+		for _, a := range f.articles {
+			if a.isDraft() && !s.opts.Drafts {
+				continue
+			}
+			e, err := s.cache.get(a.path)
+			if err != nil || e.err != nil {
+				continue
+			}
+			item := feedItem{
+				article: e.article,
+				link:    l.article(a.path),
+				date:    e.article.meta.Date,
+				body:    e.body,
+			}
+			if item.date.IsZero() {
+				item.date = e.modTime
+			}
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].date.Equal(items[j].date) {
+			return items[i].date.After(items[j].date)
+		}
+		return items[i].article.path < items[j].article.path
+	})
+	if 0 <= size && size < len(items) {
+		items = items[:size]
+	}
+	for i := range items {
+		a := items[i].article
+		items[i].html, _ = s.renderArticle(a, s.wikiLinks(w, items[i].body, a.path), l)
+	}
+	return
+}
+
+// rss is RSS 2.0 feed
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Author      string `xml:"author,omitempty"`
+	Description string `xml:"description"`
+}
+
+// atom is Atom feed
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary,omitempty"`
+	Content atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// baseURL return base URL of blog from options or
+// scheme and host of request
+func (s *Server) baseURL(r *http.Request) string {
+	if s.opts.URL != "" {
+		return s.opts.URL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedHandler generate feed of recent articles in RSS or Atom format
+func (s *Server) feedHandler(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := func() (err error) {
+			defer func() {
+				if err != nil {
+					err = errorf(statusCode(err), "Try generate feed: %v. %v", r.URL.Path, err)
+				}
+			}()
+			base := s.baseURL(r)
+			items, err := s.feedItems(base, s.opts.FeedSize)
+			if err != nil {
+				return
+			}
+
+			var feed interface{}
+			switch format {
+			case "rss":
+				feed = rssFeed(s.opts.Title, base, items)
+				w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+			default:
+				feed = atomFeed(s.opts.Title, base, base+r.URL.Path, items)
+				w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+			}
+			out, err := xml.MarshalIndent(feed, "", "\t")
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "%s%s\n", xml.Header, out)
+			return
+		}(); err != nil {
+			s.writeError(w, r, err)
+		}
+	}
+}
+
+// rssFeed return RSS 2.0 feed
+func rssFeed(title, base string, items []feedItem) (f rss) {
+	f.Version = "2.0"
+	f.Channel = rssChannel{
+		Title:       title,
+		Link:        base + "/",
+		Description: "Recent articles",
+	}
+	if 0 < len(items) {
+		f.Channel.LastBuildDate = items[0].date.Format(time.RFC1123Z)
+	}
+	for _, item := range items {
+		f.Channel.Items = append(f.Channel.Items, rssItem{
+			Title:       item.article.name,
+			Link:        item.link,
+			GUID:        item.link,
+			PubDate:     item.date.Format(time.RFC1123Z),
+			Author:      item.article.meta.Author,
+			Description: string(item.html),
+		})
+	}
+	return
+}
+
+// atomFeed return Atom feed
+func atomFeed(title, base, self string, items []feedItem) (f atom) {
+	f.Title = title
+	f.ID = base + "/"
+	f.Links = []atomLink{{Href: base + "/"}, {Href: self, Rel: "self"}}
+	f.Updated = time.Unix(0, 0).UTC().Format(time.RFC3339)
+	if 0 < len(items) {
+		f.Updated = items[0].date.Format(time.RFC3339)
+	}
+	for _, item := range items {
+		e := atomEntry{
+			Title:   item.article.name,
+			ID:      item.link,
+			Links:   []atomLink{{Href: item.link}},
+			Updated: item.date.Format(time.RFC3339),
+			Summary: item.article.meta.Summary,
+			Content: atomContent{Type: "html", Body: string(item.html)},
+		}
+		if author := item.article.meta.Author; author != "" {
+			e.Author = &atomAuthor{Name: author}
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return
+}
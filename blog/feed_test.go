@@ -0,0 +1,124 @@
+package blog
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"old.md": "---\ntitle: Old\ndate: 2019-01-01\n---\ntext",
+		"new.md": "---\ntitle: New\ndate: 2019-05-17\nauthor: Konstantin\ntags: [go]\n---\n" +
+			"**bold** [middle](mid.md) [[Middle]] ![img](img.png)",
+		"mid.md":   "---\ntitle: Middle\ndate: 2019-03-01\n---\ntext",
+		"draft.md": "---\ntitle: Draft\ndate: 2020-01-01\ndraft: true\n---\ntext",
+	})
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	items, err := s.feedItems("http://example.com", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.article.name)
+	}
+	if n := strings.Join(names, ","); n != "New,Middle" {
+		t.Fatalf("not valid items: %s", n)
+	}
+	if items[0].link != "http://example.com/articles/.%2Fnew.md" {
+		t.Errorf("not valid link: %s", items[0].link)
+	}
+	// content of article without page header and backlinks
+	for _, c := range []string{
+		`<a href="http://example.com/articles/.%2Fmid.md">middle</a>`,
+		`<a href="http://example.com/articles/.%2Fmid.md">Middle</a>`,
+		`<img src="http://example.com/articles/.%2Fimg.png" alt="img" />`,
+	} {
+		if !strings.Contains(string(items[0].html), c) {
+			t.Errorf("cannot find `%s` in:\n%s", c, string(items[0].html))
+		}
+	}
+	for _, item := range items {
+		for _, c := range []string{"Main page", "/tags/", "Linked from", `href="/`} {
+			if strings.Contains(string(item.html), c) {
+				t.Errorf("found `%s` in:\n%s", c, string(item.html))
+			}
+		}
+	}
+
+	t.Run("rss", func(t *testing.T) {
+		out, err := xml.Marshal(rssFeed("Blog", "http://example.com", items))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f rss
+		if err := xml.Unmarshal(out, &f); err != nil {
+			t.Fatal(err)
+		}
+		if f.Channel.Title != "Blog" || len(f.Channel.Items) != 2 {
+			t.Fatalf("not valid channel: %#v", f.Channel)
+		}
+		item := f.Channel.Items[0]
+		if item.PubDate != "Fri, 17 May 2019 00:00:00 +0000" ||
+			item.Author != "Konstantin" ||
+			!strings.Contains(item.Description, "<strong>bold</strong>") {
+			t.Errorf("not valid item: %#v", item)
+		}
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		out, err := xml.Marshal(atomFeed("Blog", "http://example.com", "http://example.com/atom.xml", items))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f atom
+		if err := xml.Unmarshal(out, &f); err != nil {
+			t.Fatal(err)
+		}
+		if f.Updated != "2019-05-17T00:00:00Z" || len(f.Entries) != 2 {
+			t.Fatalf("not valid feed: %#v", f)
+		}
+		if e := f.Entries[0]; e.Author == nil || e.Content.Type != "html" ||
+			!strings.Contains(e.Content.Body, "<strong>bold</strong>") {
+			t.Errorf("not valid entry: %#v", e)
+		}
+	})
+}
+
+func TestFeedHandler(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	for _, tc := range []struct {
+		format, contentType string
+	}{
+		{"rss", "application/rss+xml; charset=utf-8"},
+		{"atom", "application/atom+xml; charset=utf-8"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.feedHandler(tc.format)(w, httptest.NewRequest("GET", "/feed.xml", nil))
+			if ct := w.Header().Get("Content-Type"); ct != tc.contentType {
+				t.Errorf("not valid content type: %s", ct)
+			}
+			if !strings.HasPrefix(w.Body.String(), xml.Header) {
+				t.Errorf("not valid feed: %s", w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "Work in progress") {
+				t.Errorf("draft in feed")
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Konstantin8105/md/internal/values"
+)
+
+// meta is metadata of article from front matter
+type meta struct {
+	Title   string
+	Date    time.Time
+	Tags    []string
+	Draft   bool
+	Summary string
+	Author  string
+	// NoTOC is true if table of contents is disabled by "toc: false"
+	NoTOC bool
+}
+
+// dateLayouts is supported layouts of date in front matter
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseFrontMatter split content of article to metadata and markdown body.
+// Front matter is located at the begin of file between lines "---" for YAML
+// or between lines "+++" for TOML:
+//
+//	---
+//	title: Article name
+//	date: 2019-05-17
+//	tags: [go, web]
+//	draft: true
+//	---
+//
+// Only simple values and lists are supported.
+// Content without front matter is returned as body.
+func parseFrontMatter(content []byte) (m meta, body []byte, err error) {
+	content = bytes.Replace(content, []byte("\r"), []byte(""), -1)
+	body = content
+
+	var delimiter, separator string
+	switch {
+	case bytes.HasPrefix(content, []byte("---\n")):
+		delimiter, separator = "---", ":"
+	case bytes.HasPrefix(content, []byte("+++\n")):
+		delimiter, separator = "+++", "="
+	default:
+		return
+	}
+
+	lines := strings.Split(string(content), "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == delimiter {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		// front matter is not closed, so that is not front matter
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot parse front matter: %v", err)
+		}
+	}()
+
+	err = values.Parse(lines[1:end], separator, 2, m.set)
+	if _, ok := err.(*values.SyntaxError); ok {
+		// content between horizontal rules is not front matter
+		return meta{}, content, nil
+	}
+	if err != nil {
+		return
+	}
+
+	body = []byte(strings.Join(lines[end+1:], "\n"))
+	return
+}
+
+// set value of metadata by key. Unknown keys are ignored.
+func (m *meta) set(key string, values []string) (err error) {
+	value := strings.Join(values, " ")
+	switch key {
+	case "title":
+		m.Title = value
+	case "date":
+		m.Date, err = parseDate(value)
+	case "tags", "tag":
+		m.Tags = append(m.Tags, values...)
+	case "draft":
+		m.Draft, err = strconv.ParseBool(value)
+	case "summary", "description":
+		m.Summary = value
+	case "author":
+		m.Author = value
+	case "toc":
+		var toc bool
+		toc, err = strconv.ParseBool(value)
+		m.NoTOC = !toc
+	}
+	return
+}
+
+// parseDate parse date in one of supported layouts
+func parseDate(value string) (t time.Time, err error) {
+	for _, layout := range dateLayouts {
+		if t, err = time.Parse(layout, value); err == nil {
+			return
+		}
+	}
+	return t, fmt.Errorf("not valid date `%s`", value)
+}
@@ -0,0 +1,113 @@
+package blog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFrontMatter(t *testing.T) {
+	tcs := []struct {
+		content string
+		meta    meta
+		body    string
+		isErr   bool
+	}{
+		{
+			content: "# Article\n\ntext",
+			body:    "# Article\n\ntext",
+		},
+		{
+			content: "---\ntitle: Name\ndate: 2019-05-17\ndraft: true\n" +
+				"tags: [go, \"web\"]\nauthor: 'Konstantin'\nsummary: Short\n---\n# Article\n",
+			meta: meta{
+				Title:   "Name",
+				Date:    time.Date(2019, 5, 17, 0, 0, 0, 0, time.UTC),
+				Tags:    []string{"go", "web"},
+				Draft:   true,
+				Summary: "Short",
+				Author:  "Konstantin",
+			},
+			body: "# Article\n",
+		},
+		{
+			content: "---\r\ntags:\r\n  - go\r\n  - web\r\nunknown: value\r\n---\r\ntext",
+			meta:    meta{Tags: []string{"go", "web"}},
+			body:    "text",
+		},
+		{
+			content: "+++\ntitle = \"Name\"\ndate = 2019-05-17T10:20:30Z\n" +
+				"tags = [\"go\"]\n[params]\n+++\ntext",
+			meta: meta{
+				Title: "Name",
+				Date:  time.Date(2019, 5, 17, 10, 20, 30, 0, time.UTC),
+				Tags:  []string{"go"},
+			},
+			body: "text",
+		},
+		{
+			// front matter is not closed
+			content: "---\ntitle: Name\n",
+			body:    "---\ntitle: Name\n",
+		},
+		{
+			content: "---\ndate: yesterday\n---\n",
+			isErr:   true,
+		},
+		{
+			content: "---\ndraft: maybe\n---\n",
+			isErr:   true,
+		},
+		{
+			// horizontal rules around text
+			content: "---\nSome intro\n---\n\nbody",
+			body:    "---\nSome intro\n---\n\nbody",
+		},
+		{
+			content: "---\ntitle: Name\nJust text\n---\r\nbody",
+			body:    "---\ntitle: Name\nJust text\n---\nbody",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			m, body, err := parseFrontMatter([]byte(tc.content))
+			if tc.isErr {
+				if err == nil {
+					t.Fatalf("error is not found")
+				}
+				t.Logf("%v", err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a, b := fmt.Sprintf("%#v", m), fmt.Sprintf("%#v", tc.meta); a != b {
+				t.Errorf("metadata is not same:\n%s\n%s", a, b)
+			}
+			if string(body) != tc.body {
+				t.Errorf("body is not same:\n%q\n%q", string(body), tc.body)
+			}
+		})
+	}
+}
+
+func TestSortArticles(t *testing.T) {
+	date := func(day int) meta {
+		return meta{Date: time.Date(2019, 5, day, 0, 0, 0, 0, time.UTC)}
+	}
+	articles := []article{
+		{path: "d.md"},
+		{path: "old.md", meta: date(1)},
+		{path: "a.md"},
+		{path: "new.md", meta: date(17)},
+	}
+	sortArticles(articles)
+	var paths string
+	for _, a := range articles {
+		paths += a.path + " "
+	}
+	if expect := "new.md old.md a.md d.md "; paths != expect {
+		t.Errorf("not valid order: `%s` != `%s`", paths, expect)
+	}
+}
@@ -0,0 +1,329 @@
+package blog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// highlightCSS is style of highlighted code
+const highlightCSS string = `
+			.hl-k { color: #d73a49; font-weight: bold; }
+			.hl-t { color: #6f42c1; }
+			.hl-l { color: #005cc5; }
+			.hl-n { color: #005cc5; }
+			.hl-s { color: #032f62; }
+			.hl-c { color: #6a737d; font-style: italic; }
+			.hl-p { color: #e36209; }
+			.hl-v { color: #e36209; }
+			.hl-a { color: #22863a; }`
+
+// language is rules of syntax highlighting for programming language
+type language struct {
+	keywords map[string]bool
+	types    map[string]bool
+	literals map[string]bool
+	// lineComments is prefixes of comments up to end of line
+	lineComments []string
+	// blockComment is begin and end of block comment
+	blockComment [2]string
+	// quotes is characters of strings
+	quotes string
+	// rawQuote is character of multiline string without escapes
+	rawQuote byte
+	// ignoreCase is true for case insensitive keywords
+	ignoreCase bool
+	// variables is true for shell variables like $HOME
+	variables bool
+	// keys is true for keys of objects like `key:`
+	keys bool
+	// preprocessor is true for lines started with #
+	preprocessor bool
+}
+
+// words return set of words
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// languages is supported languages of syntax highlighting
+var languages = map[string]*language{
+	"go": {
+		keywords: words(`break case chan const continue default defer else
+			fallthrough for func go goto if import interface map package range
+			return select struct switch type var`),
+		types: words(`bool byte complex64 complex128 error float32 float64 int
+			int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64
+			uintptr`),
+		literals:     words(`true false nil iota`),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		rawQuote:     '`',
+	},
+	"c": {
+		keywords: words(`auto break case const continue default do else enum
+			extern for goto if inline register restrict return sizeof static
+			struct switch typedef union volatile while`),
+		types: words(`char double float int long short signed unsigned void
+			size_t int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t
+			uint64_t bool FILE`),
+		literals:     words(`NULL true false`),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+		preprocessor: true,
+	},
+	"sh": {
+		keywords: words(`if then else elif fi for while until do done case esac
+			in function return exit export local readonly shift break continue`),
+		types:        words(`echo cd pwd ls cat grep sed awk test read source set unset`),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		variables:    true,
+	},
+	"json": {
+		literals: words(`true false null`),
+		quotes:   "\"",
+		keys:     true,
+	},
+	"yaml": {
+		literals:     words(`true false null yes no on off ~`),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+		keys:         true,
+	},
+	"sql": {
+		keywords: words(`select from where and or not insert into values update
+			set delete create table drop alter add index primary key foreign
+			references join left right inner outer on as group by order having
+			limit offset distinct union all in is like between exists case when
+			then else end begin commit rollback default unique view`),
+		types: words(`int integer bigint smallint serial real float double
+			decimal numeric char varchar text boolean date time timestamp blob`),
+		literals:     words(`null true false`),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+		ignoreCase:   true,
+	},
+}
+
+// languageAliases is other names of supported languages
+var languageAliases = map[string]string{
+	"golang": "go",
+	"h":      "c",
+	"bash":   "sh",
+	"shell":  "sh",
+	"zsh":    "sh",
+	"yml":    "yaml",
+}
+
+// findLanguage return language by info string of fenced code block
+func findLanguage(info string) (*language, bool) {
+	name := strings.ToLower(strings.TrimSpace(info))
+	if fields := strings.Fields(name); 0 < len(fields) {
+		name = fields[0]
+	}
+	if alias, ok := languageAliases[name]; ok {
+		name = alias
+	}
+	lang, ok := languages[name]
+	return lang, ok
+}
+
+// escapeCode escape html characters same as blackfriday
+func escapeCode(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		"\"", "&quot;",
+	).Replace(s)
+}
+
+// isWordStart return true for first character of identifier
+func isWordStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// isWord return true for character of identifier
+func isWord(c byte) bool {
+	return isWordStart(c) || ('0' <= c && c <= '9')
+}
+
+// highlight return html of code with highlighted tokens
+func (lang *language) highlight(code string) string {
+	var out strings.Builder
+	span := func(class, text string) {
+		out.WriteString(`<span class="hl-` + class + `">`)
+		out.WriteString(escapeCode(text))
+		out.WriteString(`</span>`)
+	}
+	// lineStart is true if only spaces are before position in line
+	lineStart := true
+
+	for i := 0; i < len(code); {
+		c := code[i]
+		rest := code[i:]
+
+		// block comment
+		if b := lang.blockComment; b[0] != "" && strings.HasPrefix(rest, b[0]) {
+			end := strings.Index(rest[len(b[0]):], b[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(b[0]) + len(b[1])
+			}
+			span("c", rest[:end])
+			i += end
+			lineStart = false
+			continue
+		}
+
+		// line comment and preprocessor
+		isComment := false
+		for _, prefix := range lang.lineComments {
+			if strings.HasPrefix(rest, prefix) {
+				isComment = true
+			}
+		}
+		if isComment || (lang.preprocessor && lineStart && c == '#') {
+			end := strings.Index(rest, "\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			class := "c"
+			if !isComment {
+				class = "p"
+			}
+			span(class, rest[:end])
+			i += end
+			continue
+		}
+
+		switch {
+		case strings.IndexByte(lang.quotes, c) >= 0 || (lang.rawQuote != 0 && c == lang.rawQuote):
+			// string
+			end := 1
+			for ; end < len(rest); end++ {
+				if rest[end] == '\\' && c != lang.rawQuote {
+					end++
+					continue
+				}
+				if rest[end] == '\n' && c != lang.rawQuote {
+					break
+				}
+				if rest[end] == c {
+					end++
+					break
+				}
+			}
+			if len(rest) < end {
+				end = len(rest)
+			}
+			class := "s"
+			if lang.keys && isKey(rest[end:]) {
+				class = "a"
+			}
+			span(class, rest[:end])
+			i += end
+
+		case '0' <= c && c <= '9':
+			// number
+			end := 1
+			for ; end < len(rest); end++ {
+				if !(isWord(rest[end]) || rest[end] == '.') {
+					break
+				}
+			}
+			span("n", rest[:end])
+			i += end
+
+		case lang.variables && c == '$' && 1 < len(rest) && (isWord(rest[1]) || rest[1] == '{'):
+			// shell variable
+			end := 2
+			if rest[1] == '{' {
+				if index := strings.IndexByte(rest, '}'); 0 < index {
+					end = index + 1
+				}
+			} else {
+				for ; end < len(rest) && isWord(rest[end]); end++ {
+				}
+			}
+			span("v", rest[:end])
+			i += end
+
+		case isWordStart(c) || (lang.keys && lineStart && c != ' ' && c != '\t' && c != '\n' && c != '-'):
+			// identifier
+			end := 1
+			for ; end < len(rest); end++ {
+				if !(isWord(rest[end]) || (lang.keys && (rest[end] == '-' || rest[end] == '.'))) {
+					break
+				}
+			}
+			word := rest[:end]
+			key := word
+			if lang.ignoreCase {
+				key = strings.ToLower(word)
+			}
+			switch {
+			case lang.keys && isKey(rest[end:]):
+				span("a", word)
+			case lang.keywords[key]:
+				span("k", word)
+			case lang.types[key]:
+				span("t", word)
+			case lang.literals[key]:
+				span("l", word)
+			default:
+				out.WriteString(escapeCode(word))
+			}
+			i += end
+
+		default:
+			out.WriteString(escapeCode(rest[:1]))
+			i++
+			lineStart = c == '\n' || (lineStart && (c == ' ' || c == '\t'))
+			continue
+		}
+		lineStart = false
+	}
+	return out.String()
+}
+
+// isKey return true if text is started by separator of key and value
+func isKey(text string) bool {
+	text = strings.TrimLeft(text, " \t")
+	return strings.HasPrefix(text, ":")
+}
+
+// highlightRenderer is html renderer with syntax highlighting
+// of fenced code blocks
+type highlightRenderer struct {
+	*blackfriday.HTMLRenderer
+}
+
+// RenderNode render code blocks of supported languages with
+// highlighted tokens and other nodes by html renderer
+func (r highlightRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	if node.Type != blackfriday.CodeBlock {
+		return r.HTMLRenderer.RenderNode(w, node, entering)
+	}
+	lang, ok := findLanguage(string(node.Info))
+	if !ok {
+		return r.HTMLRenderer.RenderNode(w, node, entering)
+	}
+	var buf bytes.Buffer
+	status := r.HTMLRenderer.RenderNode(&buf, node, entering)
+	code := string(node.Literal)
+	out := strings.Replace(buf.String(), escapeCode(code), lang.highlight(code), 1)
+	io.WriteString(w, out)
+	return status
+}
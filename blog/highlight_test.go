@@ -0,0 +1,110 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tcs := []struct {
+		lang   string
+		code   string
+		expect string
+	}{
+		{
+			lang: "go",
+			code: "func main() {\n\t// comment\n\ts := `raw\n\"` + \"a\\\"b\" + 'c'\n\treturn nil, 0x1F\n}\n",
+			expect: `<span class="hl-k">func</span> main() {
+	<span class="hl-c">// comment</span>
+	s := <span class="hl-s">` + "`raw\n&quot;`" + `</span> + <span class="hl-s">&quot;a\&quot;b&quot;</span> + <span class="hl-s">'c'</span>
+	<span class="hl-k">return</span> <span class="hl-l">nil</span>, <span class="hl-n">0x1F</span>
+}
+`,
+		},
+		{
+			lang: "C",
+			code: "#include <stdio.h>\nint x = 1; /* a < b */\n",
+			expect: `<span class="hl-p">#include &lt;stdio.h&gt;</span>
+<span class="hl-t">int</span> x = <span class="hl-n">1</span>; <span class="hl-c">/* a &lt; b */</span>
+`,
+		},
+		{
+			lang: "bash",
+			code: "if [ -z \"$HOME\" ]; then echo ${PATH} # comment\nfi",
+			expect: `<span class="hl-k">if</span> [ -z <span class="hl-s">&quot;$HOME&quot;</span> ]; <span class="hl-k">then</span> <span class="hl-t">echo</span> <span class="hl-v">${PATH}</span> <span class="hl-c"># comment</span>
+<span class="hl-k">fi</span>`,
+		},
+		{
+			lang:   "json",
+			code:   "{\"key\": [1.5, true, null, \"value\"]}",
+			expect: `{<span class="hl-a">&quot;key&quot;</span>: [<span class="hl-n">1.5</span>, <span class="hl-l">true</span>, <span class="hl-l">null</span>, <span class="hl-s">&quot;value&quot;</span>]}`,
+		},
+		{
+			lang: "yml",
+			code: "# config\nserver-name: 'md'\nlist:\n  - 8080\n  enabled: true\n",
+			expect: `<span class="hl-c"># config</span>
+<span class="hl-a">server-name</span>: <span class="hl-s">'md'</span>
+<span class="hl-a">list</span>:
+  - <span class="hl-n">8080</span>
+  <span class="hl-a">enabled</span>: <span class="hl-l">true</span>
+`,
+		},
+		{
+			lang:   "sql",
+			code:   "SELECT name FROM users WHERE id = 'a' -- comment",
+			expect: `<span class="hl-k">SELECT</span> name <span class="hl-k">FROM</span> users <span class="hl-k">WHERE</span> id = <span class="hl-s">'a'</span> <span class="hl-c">-- comment</span>`,
+		},
+		{
+			lang:   "sh",
+			code:   "echo Привет мир > файл.txt\n",
+			expect: "<span class=\"hl-t\">echo</span> Привет мир &gt; файл.txt\n",
+		},
+		{
+			lang:   "sql",
+			code:   "SELECT имя FROM «пользователи» WHERE é = 1",
+			expect: `<span class="hl-k">SELECT</span> имя <span class="hl-k">FROM</span> «пользователи» <span class="hl-k">WHERE</span> é = <span class="hl-n">1</span>`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.lang, func(t *testing.T) {
+			lang, ok := findLanguage(tc.lang)
+			if !ok {
+				t.Fatalf("language is not found")
+			}
+			if out := lang.highlight(tc.code); out != tc.expect {
+				t.Errorf("not valid highlighting:\n%s", ShowDiff(out, tc.expect))
+			}
+		})
+	}
+}
+
+func TestHighlightRender(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	tcs := []struct {
+		markdown string
+		expect   string
+	}{
+		{
+			markdown: "```go\nvar a = \"<b>\"\n```\n",
+			expect:   `<pre><code class="language-go"><span class="hl-k">var</span> a = <span class="hl-s">&quot;&lt;b&gt;&quot;</span>` + "\n</code></pre>",
+		},
+		{
+			markdown: "```unknown\nvar a = \"<b>\"\n```\n",
+			expect:   `<pre><code class="language-unknown">var a = &quot;&lt;b&gt;&quot;` + "\n</code></pre>",
+		},
+		{
+			markdown: "    var a\n",
+			expect:   "<pre><code>var a\n</code></pre>",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.markdown, func(t *testing.T) {
+			out := string(s.render([]byte(tc.markdown)))
+			if !strings.Contains(out, tc.expect) {
+				t.Errorf("cannot find:\n%s\nin:\n%s", tc.expect, out)
+			}
+		})
+	}
+}
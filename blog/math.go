@@ -0,0 +1,609 @@
+package blog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday"
+)
+
+// mathSpan is formula of article in TeX notation
+type mathSpan struct {
+	tex string
+	// display is true for formula $$...$$ shown as block
+	display bool
+}
+
+// mathToken return placeholder of formula, which is not changed by
+// markdown parser
+func mathToken(index int) string {
+	return fmt.Sprintf("mathplaceholder%dx", index)
+}
+
+// extractMath return markdown with formulas $...$ and $$...$$ replaced
+// by placeholders, so formulas are protected from markdown parsing.
+// Code blocks, code spans, raw html and addresses of links are not changed.
+func (s *Server) extractMath(markdown []byte) ([]byte, []mathSpan) {
+	var out, text strings.Builder
+	// sources is formulas and escaped dollars by index of placeholder
+	var sources []string
+	flush := func() {
+		out.WriteString(replaceMath(text.String(), &sources))
+		text.Reset()
+	}
+	var fence string
+	for _, line := range strings.SplitAfter(string(markdown), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			out.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fence = trimmed[:3]
+			out.WriteString(line)
+			continue
+		}
+		text.WriteString(line)
+	}
+	flush()
+	if len(sources) == 0 {
+		return []byte(out.String()), nil
+	}
+
+	code := s.codePlaceholders([]byte(out.String()), mathPlaceholder)
+	var spans []mathSpan
+	return replacePlaceholders([]byte(out.String()), mathPlaceholder, func(index int) string {
+		source := sources[index]
+		switch {
+		case code[index]:
+			return source
+		case source == "\\$":
+			return "$"
+		}
+		tex, _, display, _ := mathAt(source)
+		spans = append(spans, mathSpan{tex: tex, display: display})
+		return mathToken(len(spans) - 1)
+	}), spans
+}
+
+// replaceMath replace formulas and escaped dollars of markdown text
+// without code blocks by placeholders
+func replaceMath(text string, sources *[]string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '\\' && i+1 < len(text):
+			if text[i+1] == '$' {
+				out.WriteString(mathToken(len(*sources)))
+				*sources = append(*sources, text[i:i+2])
+			} else {
+				out.WriteString(text[i : i+2])
+			}
+			i += 2
+		case c == '`':
+			// code span is closed by the same amount of backticks
+			n := i
+			for n < len(text) && text[n] == '`' {
+				n++
+			}
+			ticks := text[i:n]
+			end := strings.Index(text[n:], ticks)
+			if end < 0 {
+				out.WriteString(ticks)
+				i = n
+				continue
+			}
+			end += n + len(ticks)
+			out.WriteString(text[i:end])
+			i = end
+		case c == '$':
+			_, n, _, ok := mathAt(text[i:])
+			if !ok {
+				out.WriteByte(c)
+				i++
+				continue
+			}
+			out.WriteString(mathToken(len(*sources)))
+			*sources = append(*sources, text[i:i+n])
+			i += n
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// mathAt return formula at the begin of text, length of formula with
+// delimiters and true if text starts with formula. Inline formula is
+// located in one line without spaces after opening and before closing
+// dollar, so prices like $5 and $10 are not formulas.
+func mathAt(text string) (tex string, n int, display, ok bool) {
+	if strings.HasPrefix(text, "$$") {
+		end := strings.Index(text[2:], "$$")
+		if end < 0 || strings.TrimSpace(text[2:2+end]) == "" {
+			return
+		}
+		return text[2 : 2+end], end + 4, true, true
+	}
+	end := -1
+	for i := 1; i < len(text) && end < 0; i++ {
+		switch text[i] {
+		case '\n':
+			return
+		case '\\':
+			i++
+		case '$':
+			end = i
+		}
+	}
+	if end <= 1 {
+		return
+	}
+	tex = text[1:end]
+	if isSpace(tex[0]) || isSpace(tex[len(tex)-1]) ||
+		(end+1 < len(text) && isDigit(text[end+1])) {
+		return
+	}
+	return tex, end + 1, false, true
+}
+
+// mathPlaceholder is placeholder of formula in markdown and html
+var mathPlaceholder = regexp.MustCompile(`mathplaceholder([0-9]+)x`)
+
+// mathText return html text with placeholders of formulas replaced by
+// MathML or by escaped source of formulas if source is set
+func mathText(text []byte, spans []mathSpan, source bool) []byte {
+	if !mathPlaceholder.Match(text) {
+		return text
+	}
+	return replacePlaceholders(text, mathPlaceholder, func(index int) string {
+		if len(spans) <= index {
+			return mathToken(index)
+		}
+		span := spans[index]
+		switch {
+		case source && span.display:
+			return html.EscapeString("$$" + span.tex + "$$")
+		case source:
+			return html.EscapeString("$" + span.tex + "$")
+		}
+		return mathHTML(span)
+	})
+}
+
+// mathID return identifier of heading without placeholders of formulas
+func mathID(id string) string {
+	if !mathPlaceholder.MatchString(id) {
+		return id
+	}
+	parts := strings.FieldsFunc(mathPlaceholder.ReplaceAllString(id, "-"), func(r rune) bool {
+		return r == '-'
+	})
+	if len(parts) == 0 {
+		return "formula"
+	}
+	return strings.Join(parts, "-")
+}
+
+// mathRenderer is html renderer with formulas in text of article.
+// Formulas in alternative text of images are shown as source.
+type mathRenderer struct {
+	blackfriday.Renderer
+	spans []mathSpan
+}
+
+// RenderNode render placeholders of formulas in text nodes as MathML
+// and other nodes by html renderer
+func (r mathRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	switch node.Type {
+	case blackfriday.Paragraph:
+		// display formula in separate paragraph is not wrapped
+		text := node.FirstChild
+		if !entering || text == nil || text != node.LastChild || text.Type != blackfriday.Text {
+			break
+		}
+		m := mathPlaceholder.FindSubmatch(text.Literal)
+		if m == nil || len(m[0]) != len(text.Literal) {
+			break
+		}
+		index, err := strconv.Atoi(string(m[1]))
+		if err != nil || len(r.spans) <= index || !r.spans[index].display {
+			break
+		}
+		io.WriteString(w, mathHTML(r.spans[index])+"\n")
+		return blackfriday.SkipChildren
+
+	case blackfriday.Text:
+		if !mathPlaceholder.Match(node.Literal) {
+			break
+		}
+		var buf bytes.Buffer
+		status := r.Renderer.RenderNode(&buf, node, entering)
+		source := false
+		for p := node.Parent; p != nil; p = p.Parent {
+			source = source || p.Type == blackfriday.Image
+		}
+		w.Write(mathText(buf.Bytes(), r.spans, source))
+		return status
+	}
+	return r.Renderer.RenderNode(w, node, entering)
+}
+
+// mathHTML return MathML of formula. Source of formula is shown as
+// preformatted text if formula is not supported.
+func mathHTML(span mathSpan) string {
+	ml, err := texToMathML(span.tex, span.display)
+	if err == nil {
+		return ml
+	}
+	if span.display {
+		return fmt.Sprintf("<pre class=\"math\">$$%s$$</pre>", html.EscapeString(span.tex))
+	}
+	return fmt.Sprintf("<code class=\"math\">$%s$</code>", html.EscapeString(span.tex))
+}
+
+// texParser convert formula in TeX notation to MathML
+type texParser struct {
+	tex string
+	pos int
+	// display is true for block formula with limits under
+	// and over large operators
+	display bool
+}
+
+// texToMathML return MathML of formula or error for not
+// supported construct
+func texToMathML(tex string, display bool) (string, error) {
+	p := &texParser{tex: tex, display: display}
+	body, err := p.expr(0)
+	if err != nil {
+		return "", err
+	}
+	var attr string
+	if display {
+		attr = ` display="block"`
+	}
+	return fmt.Sprintf("<math xmlns=\"http://www.w3.org/1998/Math/MathML\"%s>"+
+		"<semantics><mrow>%s</mrow><annotation encoding=\"application/x-tex\">%s</annotation></semantics></math>",
+		attr, body, html.EscapeString(strings.TrimSpace(tex))), nil
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isLetter(c byte) bool { return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') }
+
+func (p *texParser) skipSpaces() {
+	for p.pos < len(p.tex) && isSpace(p.tex[p.pos]) {
+		p.pos++
+	}
+}
+
+// expr return MathML of atoms with scripts until end of formula
+// or closing character
+func (p *texParser) expr(closing byte) (out string, err error) {
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.tex) {
+			if closing != 0 {
+				return "", fmt.Errorf("missing `%c`", closing)
+			}
+			return
+		}
+		c := p.tex[p.pos]
+		if closing != 0 && c == closing {
+			p.pos++
+			return
+		}
+		if c == '}' {
+			return "", errors.New("unexpected `}`")
+		}
+		base, limits, err := p.atom()
+		if err != nil {
+			return "", err
+		}
+		if base, err = p.scripts(base, limits); err != nil {
+			return "", err
+		}
+		out += base
+	}
+}
+
+// scripts return MathML of base with subscript and superscript.
+// Scripts of operators with limits are located under and over
+// operator in display formula.
+func (p *texParser) scripts(base string, limits bool) (string, error) {
+	var sub, sup string
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.tex) {
+			break
+		}
+		var err error
+		if c := p.tex[p.pos]; c == '_' && sub == "" {
+			p.pos++
+			sub, err = p.arg()
+		} else if c == '^' && sup == "" {
+			p.pos++
+			sup, err = p.arg()
+		} else {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	tags := [3]string{"msub", "msup", "msubsup"}
+	if limits && p.display {
+		tags = [3]string{"munder", "mover", "munderover"}
+	}
+	switch {
+	case sub != "" && sup != "":
+		return fmt.Sprintf("<%s>%s%s%s</%s>", tags[2], base, sub, sup, tags[2]), nil
+	case sub != "":
+		return fmt.Sprintf("<%s>%s%s</%s>", tags[0], base, sub, tags[0]), nil
+	case sup != "":
+		return fmt.Sprintf("<%s>%s%s</%s>", tags[1], base, sup, tags[1]), nil
+	}
+	return base, nil
+}
+
+// arg return MathML of argument of command or script. Argument is
+// group in braces or single token.
+func (p *texParser) arg() (string, error) {
+	p.skipSpaces()
+	if p.pos == len(p.tex) {
+		return "", errors.New("missing argument")
+	}
+	switch c := p.tex[p.pos]; {
+	case isDigit(c):
+		p.pos++
+		return "<mn>" + string(c) + "</mn>", nil
+	case c == '^' || c == '_' || c == '}':
+		return "", errors.New("missing argument")
+	}
+	ml, _, err := p.atom()
+	return ml, err
+}
+
+// raw return text of argument in braces without parsing
+func (p *texParser) raw() (string, error) {
+	p.skipSpaces()
+	if p.pos == len(p.tex) || p.tex[p.pos] != '{' {
+		return "", errors.New("missing `{`")
+	}
+	depth := 0
+	for i := p.pos; i < len(p.tex); i++ {
+		switch p.tex[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				text := p.tex[p.pos+1 : i]
+				p.pos = i + 1
+				return text, nil
+			}
+		}
+	}
+	return "", errors.New("missing `}`")
+}
+
+// texOperators is MathML of operator characters
+var texOperators = map[byte]string{
+	'+': "+", '-': "−", '=': "=", '<': "&lt;", '>': "&gt;",
+	'(': "(", ')': ")", '[': "[", ']': "]", '|': "|",
+	',': ",", ';': ";", ':': ":", '!': "!", '?': "?",
+	'/': "/", '*': "∗", '\'': "′",
+}
+
+// atom return MathML of token or group. Limits is true for large
+// operators with limits.
+func (p *texParser) atom() (ml string, limits bool, err error) {
+	c := p.tex[p.pos]
+	switch {
+	case c == '{':
+		p.pos++
+		ml, err = p.expr('}')
+		return "<mrow>" + ml + "</mrow>", false, err
+	case c == '\\':
+		return p.command()
+	case isLetter(c):
+		p.pos++
+		return "<mi>" + string(c) + "</mi>", false, nil
+	case isDigit(c) || c == '.':
+		start := p.pos
+		for p.pos < len(p.tex) && (isDigit(p.tex[p.pos]) || p.tex[p.pos] == '.') {
+			p.pos++
+		}
+		return "<mn>" + p.tex[start:p.pos] + "</mn>", false, nil
+	case c == '^' || c == '_':
+		// scripts without base
+		return "<mrow></mrow>", false, nil
+	case utf8.RuneStart(c) && c >= utf8.RuneSelf:
+		r, size := utf8.DecodeRuneInString(p.tex[p.pos:])
+		p.pos += size
+		return "<mi>" + html.EscapeString(string(r)) + "</mi>", false, nil
+	}
+	if op, ok := texOperators[c]; ok {
+		p.pos++
+		return "<mo>" + op + "</mo>", false, nil
+	}
+	return "", false, fmt.Errorf("unsupported character `%c`", c)
+}
+
+// texGreek is greek letters
+var texGreek = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+// texIdentifiers is symbols shown as identifiers
+var texIdentifiers = map[string]string{
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅",
+	"hbar": "ℏ", "ell": "ℓ", "aleph": "ℵ",
+}
+
+// texSymbols is symbols shown as operators
+var texSymbols = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "propto": "∝",
+	"ll": "≪", "gg": "≫", "perp": "⊥", "parallel": "∥", "angle": "∠",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "mapsto": "↦",
+	"in": "∈", "notin": "∉", "subset": "⊂", "subseteq": "⊆",
+	"supset": "⊃", "supseteq": "⊇", "cup": "∪", "cap": "∩",
+	"forall": "∀", "exists": "∃", "neg": "¬", "land": "∧", "lor": "∨",
+	"wedge": "∧", "vee": "∨", "oplus": "⊕", "otimes": "⊗", "circ": "∘",
+	"ldots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "prime": "′",
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂",
+	"int": "∫", "iint": "∬", "oint": "∮",
+	"{": "{", "}": "}", "|": "‖", "%": "%", "$": "$", "&": "&amp;", "#": "#", "_": "_",
+}
+
+// texFunctions is names of functions shown upright
+var texFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true,
+	"tanh": true, "log": true, "ln": true, "lg": true, "exp": true, "lim": true,
+	"max": true, "min": true, "sup": true, "inf": true, "det": true, "gcd": true,
+	"deg": true, "dim": true, "ker": true, "arg": true, "Pr": true,
+}
+
+// texLimits is operators and functions with limits under and over
+// in display formula
+var texLimits = map[string]bool{
+	"sum": true, "prod": true, "coprod": true, "bigcup": true, "bigcap": true,
+	"lim": true, "max": true, "min": true, "sup": true, "inf": true,
+	"det": true, "gcd": true, "Pr": true,
+}
+
+// texSpaces is width of spaces
+var texSpaces = map[string]string{
+	",": "0.1667em", ":": "0.2222em", ";": "0.2778em", "!": "-0.1667em",
+	" ": "0.3333em", "quad": "1em", "qquad": "2em",
+}
+
+// texVariants is math variants of fonts
+var texVariants = map[string]string{
+	"mathrm": "normal", "operatorname": "normal", "mathbf": "bold",
+	"mathit": "italic", "mathbb": "double-struck", "mathcal": "script",
+}
+
+// texAccents is accents over argument
+var texAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "overline": "¯", "vec": "→",
+	"dot": "˙", "ddot": "¨", "tilde": "~", "widetilde": "~",
+}
+
+// command return MathML of command
+func (p *texParser) command() (ml string, limits bool, err error) {
+	p.pos++
+	if p.pos == len(p.tex) {
+		return "", false, errors.New("missing command")
+	}
+	start := p.pos
+	if isLetter(p.tex[p.pos]) {
+		for p.pos < len(p.tex) && isLetter(p.tex[p.pos]) {
+			p.pos++
+		}
+	} else {
+		p.pos++
+	}
+	name := p.tex[start:p.pos]
+
+	if s, ok := texGreek[name]; ok {
+		return "<mi>" + s + "</mi>", false, nil
+	}
+	if s, ok := texIdentifiers[name]; ok {
+		return "<mi>" + s + "</mi>", false, nil
+	}
+	if s, ok := texSymbols[name]; ok {
+		return "<mo>" + s + "</mo>", texLimits[name], nil
+	}
+	if texFunctions[name] {
+		return "<mi>" + name + "</mi>", texLimits[name], nil
+	}
+	if width, ok := texSpaces[name]; ok {
+		return "<mspace width=\"" + width + "\"/>", false, nil
+	}
+	if variant, ok := texVariants[name]; ok {
+		var text string
+		if text, err = p.raw(); err != nil {
+			return
+		}
+		return fmt.Sprintf("<mi mathvariant=\"%s\">%s</mi>", variant, html.EscapeString(text)), false, nil
+	}
+	if accent, ok := texAccents[name]; ok {
+		var a string
+		if a, err = p.arg(); err != nil {
+			return
+		}
+		return fmt.Sprintf("<mover accent=\"true\">%s<mo>%s</mo></mover>", a, accent), false, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		var a, b string
+		if a, err = p.arg(); err != nil {
+			return
+		}
+		if b, err = p.arg(); err != nil {
+			return
+		}
+		return "<mfrac>" + a + b + "</mfrac>", false, nil
+	case "sqrt":
+		var index, a string
+		if p.skipSpaces(); p.pos < len(p.tex) && p.tex[p.pos] == '[' {
+			p.pos++
+			if index, err = p.expr(']'); err != nil {
+				return
+			}
+		}
+		if a, err = p.arg(); err != nil {
+			return
+		}
+		if index != "" {
+			return "<mroot>" + a + "<mrow>" + index + "</mrow></mroot>", false, nil
+		}
+		return "<msqrt>" + a + "</msqrt>", false, nil
+	case "text", "textrm", "mbox":
+		var text string
+		if text, err = p.raw(); err != nil {
+			return
+		}
+		return "<mtext>" + html.EscapeString(text) + "</mtext>", false, nil
+	case "left", "right":
+		// delimiter of any size
+		if p.skipSpaces(); p.pos < len(p.tex) && p.tex[p.pos] == '.' {
+			p.pos++
+			return "", false, nil
+		}
+		if p.pos == len(p.tex) {
+			return "", false, fmt.Errorf("missing delimiter of `\\%s`", name)
+		}
+		return p.atom()
+	}
+	return "", false, fmt.Errorf("unsupported command `\\%s`", name)
+}
@@ -0,0 +1,157 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractMath(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	tcs := []struct {
+		markdown string
+		expect   string
+		spans    []mathSpan
+	}{
+		{
+			markdown: "energy $E = mc^2$ of $a_1$",
+			expect:   "energy mathplaceholder0x of mathplaceholder1x",
+			spans:    []mathSpan{{tex: "E = mc^2"}, {tex: "a_1"}},
+		},
+		{
+			markdown: "sum\n\n$$\n\\sum_{i=1}^n i\n$$\n",
+			expect:   "sum\n\nmathplaceholder0x\n",
+			spans:    []mathSpan{{tex: "\n\\sum_{i=1}^n i\n", display: true}},
+		},
+		{markdown: "price $5 and $10", expect: "price $5 and $10"},
+		{markdown: "from $ 1 to 2 $ and $$ $$", expect: "from $ 1 to 2 $ and $$ $$"},
+		{markdown: "escaped \\$x\\$ \\*", expect: "escaped $x$ \\*"},
+		{markdown: "`$x$` and ``a`$b$`` and `", expect: "`$x$` and ``a`$b$`` and `"},
+		{markdown: "```\n$x$\n```\n$y$", expect: "```\n$x$\n```\nmathplaceholder0x", spans: []mathSpan{{tex: "y"}}},
+		{markdown: "$x\ny$", expect: "$x\ny$"},
+		{markdown: "text\n\n    code $x$ \\$\n\n$y$", expect: "text\n\n    code $x$ \\$\n\nmathplaceholder0x", spans: []mathSpan{{tex: "y"}}},
+		{markdown: "<div>\n$x$ \\$\n</div>\n\n$y$", expect: "<div>\n$x$ \\$\n</div>\n\nmathplaceholder0x", spans: []mathSpan{{tex: "y"}}},
+		{markdown: "a <span title=\"$x$\">$y$</span>", expect: "a <span title=\"$x$\">mathplaceholder0x</span>", spans: []mathSpan{{tex: "y"}}},
+		{markdown: "[$y$](a$x$.md \"$x$\")", expect: "[mathplaceholder0x](a$x$.md \"$x$\")", spans: []mathSpan{{tex: "y"}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.markdown, func(t *testing.T) {
+			out, spans := s.extractMath([]byte(tc.markdown))
+			if string(out) != tc.expect {
+				t.Errorf("not valid markdown:\n%s\n%s", string(out), tc.expect)
+			}
+			if len(spans) != len(tc.spans) {
+				t.Fatalf("not valid formulas: %v", spans)
+			}
+			for i := range spans {
+				if spans[i] != tc.spans[i] {
+					t.Errorf("not valid formula: %v != %v", spans[i], tc.spans[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTexToMathML(t *testing.T) {
+	tcs := []struct {
+		tex     string
+		display bool
+		expect  string
+	}{
+		{tex: "x+1", expect: "<mi>x</mi><mo>+</mo><mn>1</mn>"},
+		{tex: "a_1^2", expect: "<msubsup><mi>a</mi><mn>1</mn><mn>2</mn></msubsup>"},
+		{tex: "x^23", expect: "<msup><mi>x</mi><mn>2</mn></msup><mn>3</mn>"},
+		{tex: "e^{-x}", expect: "<msup><mi>e</mi><mrow><mo>−</mo><mi>x</mi></mrow></msup>"},
+		{tex: "\\frac{\\alpha}{2}", expect: "<mfrac><mrow><mi>α</mi></mrow><mrow><mn>2</mn></mrow></mfrac>"},
+		{tex: "\\sqrt[3]{x}", expect: "<mroot><mrow><mi>x</mi></mrow><mrow><mn>3</mn></mrow></mroot>"},
+		{tex: "\\sin x \\le 1", expect: "<mi>sin</mi><mi>x</mi><mo>≤</mo><mn>1</mn>"},
+		{tex: "\\text{if } a<b", expect: "<mtext>if </mtext><mi>a</mi><mo>&lt;</mo><mi>b</mi>"},
+		{tex: "\\mathbf{v}", expect: "<mi mathvariant=\"bold\">v</mi>"},
+		{tex: "\\vec a", expect: "<mover accent=\"true\"><mi>a</mi><mo>→</mo></mover>"},
+		{tex: "\\left( x \\right.", expect: "<mo>(</mo><mi>x</mi>"},
+		{tex: "\\sum_{i=1}^n", expect: "<msubsup><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup>"},
+		{
+			tex:     "\\sum_{i=1}^n",
+			display: true,
+			expect:  "<munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover>",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.tex, func(t *testing.T) {
+			ml, err := texToMathML(tc.tex, tc.display)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(ml, "<mrow>"+tc.expect+"</mrow><annotation") {
+				t.Errorf("not valid MathML:\n%s\n%s", ml, tc.expect)
+			}
+			if tc.display != strings.Contains(ml, `display="block"`) {
+				t.Errorf("not valid display of MathML: %s", ml)
+			}
+		})
+	}
+
+	for _, tex := range []string{
+		"\\begin{matrix} a & b \\end{matrix}",
+		"\\unknown",
+		"{x",
+		"x}",
+		"\\frac{1}",
+		"x^",
+		"a \\\\ b",
+	} {
+		if ml, err := texToMathML(tex, false); err == nil {
+			t.Errorf("formula `%s` is not supported: %s", tex, ml)
+		}
+	}
+}
+
+func TestMathRender(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	markdown := "# Formula $a_b$\n\n## Energy $E=mc^2$ of body\n\n## $x$\n\n" +
+		"Let $x_1 * y_1 * z_1$ be.\n\n$$x_{i} = \\frac{a_i}{2}$$\n\n" +
+		"$$\\begin{matrix}a & b\\end{matrix}$$\n\nand $\\unknown<x>$ too.\n\n" +
+		"[link $y$](b.md) ![image $a<b$](img.png)\n\n    code $x$\n\n<div>$y$</div>\n"
+	html, toc := s.renderArticle(article{path: "./a.md"}, []byte(markdown), s.links())
+	for _, c := range []string{
+		"<mi>x</mi><mn>1</mn></msub><mo>∗</mo>",
+		"<math xmlns=\"http://www.w3.org/1998/Math/MathML\" display=\"block\">",
+		"<pre class=\"math\">$$\\begin{matrix}a &amp; b\\end{matrix}$$</pre>",
+		"and <code class=\"math\">$\\unknown&lt;x&gt;$</code> too.",
+		"<h1 id=\"formula\">Formula <math ",
+		"<h2 id=\"energy-of-body\">Energy <math ",
+		"<h2 id=\"formula-1\"><math ",
+		"<a href=\"/articles/.%2Fb.md\">link <math ",
+		"alt=\"image $a&lt;b$\"",
+		"<pre><code>code $x$\n</code></pre>",
+		"<div>$y$</div>",
+	} {
+		if !strings.Contains(string(html), c) {
+			t.Errorf("cannot find `%s` in:\n%s", c, string(html))
+		}
+	}
+	for _, c := range []string{"<em>", "mathplaceholder", "<p><math"} {
+		if strings.Contains(string(html), c) {
+			t.Errorf("found `%s` in:\n%s", c, string(html))
+		}
+	}
+	for _, c := range []string{
+		"<a href=\"#formula\">Formula <math ",
+		"<msub><mi>a</mi><mi>b</mi></msub>",
+		"<a href=\"#energy-of-body\">Energy <math ",
+		"<a href=\"#formula-1\"><math ",
+	} {
+		if !strings.Contains(string(toc), c) {
+			t.Errorf("cannot find `%s` in table of contents:\n%s", c, string(toc))
+		}
+	}
+
+	s.opts.NoMath = true
+	html, _ = s.renderArticle(article{path: "./a.md"}, []byte("$x$"), s.links())
+	if !strings.Contains(string(html), "$x$") {
+		t.Errorf("formula is rendered:\n%s", string(html))
+	}
+}
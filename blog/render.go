@@ -0,0 +1,162 @@
+package blog
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// parseMarkdown return AST of markdown
+func (s *Server) parseMarkdown(markdown []byte) *blackfriday.Node {
+	parser := blackfriday.New(blackfriday.WithExtensions(s.opts.Extensions))
+	return parser.Parse(markdown)
+}
+
+// renderNode return html of markdown AST with formulas
+func (s *Server) renderNode(ast *blackfriday.Node, spans []mathSpan) []byte {
+	var r blackfriday.Renderer = blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: blackfriday.CommonHTMLFlags,
+	})
+	if !s.opts.NoHighlight {
+		r = highlightRenderer{r.(*blackfriday.HTMLRenderer)}
+	}
+	if len(spans) != 0 {
+		r = mathRenderer{r, spans}
+	}
+	var buf bytes.Buffer
+	r.RenderHeader(&buf, ast)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return r.RenderNode(&buf, node, entering)
+	})
+	r.RenderFooter(&buf, ast)
+	return buf.Bytes()
+}
+
+// render return html of markdown page
+func (s *Server) render(markdown []byte) []byte {
+	return s.renderNode(s.parseMarkdown(markdown), nil)
+}
+
+// renderArticle return html of article and html of table of contents.
+// Table of contents is empty if article have less two headings or
+// disabled in front matter or in configuration. Formulas are rendered
+// to MathML.
+func (s *Server) renderArticle(a article, markdown []byte, l links) (html, toc []byte) {
+	var spans []mathSpan
+	if !s.opts.NoMath {
+		markdown, spans = s.extractMath(markdown)
+	}
+	ast := s.parseMarkdown(markdown)
+	rewriteLinks(ast, a.path, s.opts.Photos, l)
+	hs := headings(ast)
+	if !s.opts.NoTOC && !a.meta.NoTOC {
+		// placeholders of formulas are left only in text of headings
+		toc = mathText(tocHTML(hs), spans, false)
+	}
+	return s.renderNode(ast, spans), toc
+}
+
+// codePlaceholders return indexes of placeholders, which are located in
+// code, raw html or addresses of links of markdown
+func (s *Server) codePlaceholders(markdown []byte, placeholder *regexp.Regexp) map[int]bool {
+	code := map[int]bool{}
+	find := func(text []byte) {
+		replacePlaceholders(text, placeholder, func(index int) string {
+			code[index] = true
+			return ""
+		})
+	}
+	s.parseMarkdown(markdown).Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		switch node.Type {
+		case blackfriday.Code, blackfriday.CodeBlock, blackfriday.HTMLBlock, blackfriday.HTMLSpan:
+			find(node.Literal)
+		case blackfriday.Link, blackfriday.Image:
+			find(node.LinkData.Destination)
+			find(node.LinkData.Title)
+		}
+		return blackfriday.GoToNext
+	})
+	return code
+}
+
+// replacePlaceholders return text with placeholders replaced by result
+// of replace for index of placeholder
+func replacePlaceholders(text []byte, placeholder *regexp.Regexp, replace func(index int) string) []byte {
+	return placeholder.ReplaceAllFunc(text, func(token []byte) []byte {
+		index, err := strconv.Atoi(string(placeholder.FindSubmatch(token)[1]))
+		if err != nil {
+			return token
+		}
+		return []byte(replace(index))
+	})
+}
+
+// rewriteLinks change relative links and images of article to addresses
+// of files in folder of article or photos in photos folder. Absolute
+// addresses of articles, photos and tags of server are changed to
+// addresses of links, so static site works from any file server. External
+// addresses, other pages of server and files outside of root folder are
+// not changed.
+func rewriteLinks(ast *blackfriday.Node, from, photos string, l links) {
+	dir := path.Dir(filepath.ToSlash(from))
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || (node.Type != blackfriday.Link && node.Type != blackfriday.Image) ||
+			node.LinkData.NoteID != 0 {
+			return blackfriday.GoToNext
+		}
+		u, err := url.Parse(string(node.LinkData.Destination))
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Opaque != "" || u.Path == "" {
+			return blackfriday.GoToNext
+		}
+		var target, link string
+		switch {
+		case strings.HasPrefix(u.Path, "/articles/"):
+			// path of article is escaped as query
+			name, err := url.QueryUnescape(strings.TrimPrefix(u.EscapedPath(), "/articles/"))
+			if err != nil {
+				return blackfriday.GoToNext
+			}
+			target = path.Clean(name)
+		case strings.HasPrefix(u.Path, "/"+photos+"/"):
+			target = path.Clean(strings.TrimPrefix(u.Path, "/"))
+		case strings.HasPrefix(u.Path, "/tags/"):
+			link = l.tag(strings.TrimPrefix(u.Path, "/tags/"))
+		case strings.HasPrefix(u.Path, "/"):
+			return blackfriday.GoToNext
+		default:
+			target = path.Join(dir, u.Path)
+		}
+		if target == ".." || strings.HasPrefix(target, "../") {
+			return blackfriday.GoToNext
+		}
+		switch name := strings.TrimPrefix(target, photos+"/"); {
+		case link != "":
+			// page of tag
+		case target == photos:
+			link = l.album("")
+		case name == target:
+			// paths of articles inside root folder start with "./"
+			link = l.article("./" + target)
+		case strings.HasSuffix(u.Path, "/") || path.Ext(name) == "":
+			// folder of album
+			link = l.album(name)
+		default:
+			album := path.Dir(name)
+			if album == "." {
+				album = ""
+			}
+			link = l.photo(album, path.Base(name))
+		}
+		if u.Fragment != "" {
+			link += "#" + u.Fragment
+		}
+		node.LinkData.Destination = []byte(link)
+		return blackfriday.GoToNext
+	})
+}
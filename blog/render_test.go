@@ -0,0 +1,93 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	tcs := []struct {
+		markdown string
+		server   string
+		static   string
+	}{
+		{
+			markdown: "[x](sibling.md)",
+			server:   `<a href="/articles/.%2Fnotes%2Fgo%2Fsibling.md">x</a>`,
+			static:   `<a href="../../../articles/notes/go/sibling.html">x</a>`,
+		},
+		{
+			markdown: "[see also](../other/note.md#part)",
+			server:   `<a href="/articles/.%2Fnotes%2Fother%2Fnote.md#part">see also</a>`,
+			static:   `<a href="../../../articles/notes/other/note.html#part">see also</a>`,
+		},
+		{
+			markdown: "![img](img/a%20b.png)",
+			server:   `<img src="/articles/.%2Fnotes%2Fgo%2Fimg%2Fa+b.png" alt="img" />`,
+			static:   `<img src="../../../articles/notes/go/img/a%20b.png" alt="img" />`,
+		},
+		{
+			markdown: "[x][1]\n\n[1]: ./sub/b.md",
+			server:   `<a href="/articles/.%2Fnotes%2Fgo%2Fsub%2Fb.md">x</a>`,
+			static:   `<a href="../../../articles/notes/go/sub/b.html">x</a>`,
+		},
+		{
+			markdown: "![photo](../../photos/album/a.jpg) ![photo](../../photos/b.jpg)",
+			server:   `<img src="/photos/album/a.jpg" alt="photo" /> <img src="/photos/b.jpg" alt="photo" />`,
+			static:   `<img src="../../../photos/album/a.jpg" alt="photo" /> <img src="../../../photos/b.jpg" alt="photo" />`,
+		},
+		{markdown: "[x](#part)", server: `<a href="#part">x</a>`, static: `<a href="#part">x</a>`},
+		{
+			markdown: "[x](/articles/.%2Fsub%2Fb.md#part) [x](/articles/.%2F..%2Fb.md)",
+			server:   `<a href="/articles/.%2Fsub%2Fb.md#part">x</a> <a href="/articles/.%2F..%2Fb.md">x</a>`,
+			static:   `<a href="../../../articles/sub/b.html#part">x</a> <a href="/articles/.%2F..%2Fb.md">x</a>`,
+		},
+		{
+			markdown: "![photo](/photos/album/a.jpg) [album](/photos/album) [albums](/photos/)",
+			server:   `<img src="/photos/album/a.jpg" alt="photo" /> <a href="/photos/album">album</a> <a href="/photos/">albums</a>`,
+			static: `<img src="../../../photos/album/a.jpg" alt="photo" /> <a href="../../../photos/album/index.html">album</a> ` +
+				`<a href="../../../photos/index.html">albums</a>`,
+		},
+		{
+			markdown: "[x](/tags/) [x](/tags/go)",
+			server:   `<a href="/tags/">x</a> <a href="/tags/go">x</a>`,
+			static:   `<a href="../../../tags/index.html">x</a> <a href="../../../tags/go.html">x</a>`,
+		},
+		{markdown: "[x](/search?q=a)", server: `<a href="/search?q=a">x</a>`, static: `<a href="/search?q=a">x</a>`},
+		{
+			markdown: "[x](https://example.com/a.md)",
+			server:   `<a href="https://example.com/a.md">x</a>`,
+			static:   `<a href="https://example.com/a.md">x</a>`,
+		},
+		{
+			markdown: "[x](mailto:a@example.com)",
+			server:   `<a href="mailto:a@example.com">x</a>`,
+			static:   `<a href="mailto:a@example.com">x</a>`,
+		},
+		{
+			markdown: "[x](../../../outside.md)",
+			server:   `<a href="../../../outside.md">x</a>`,
+			static:   `<a href="../../../outside.md">x</a>`,
+		},
+	}
+	a := article{path: "./notes/go/a.md"}
+	for _, tc := range tcs {
+		t.Run(tc.markdown, func(t *testing.T) {
+			for _, l := range []struct {
+				links  links
+				expect string
+			}{
+				{s.links(), tc.server},
+				{s.staticLinks("articles/notes/go/a.html"), tc.static},
+			} {
+				html, _ := s.renderArticle(a, []byte(tc.markdown), l.links)
+				if !strings.Contains(string(html), l.expect) {
+					t.Errorf("cannot find `%s` in:\n%s", l.expect, string(html))
+				}
+			}
+		})
+	}
+}
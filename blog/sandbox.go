@@ -0,0 +1,95 @@
+package blog
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// assetExtensions is extensions of files served as is
+var assetExtensions = map[string]bool{
+	// images
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".svg": true, ".webp": true, ".bmp": true, ".ico": true,
+	// video and audio
+	".mp4": true, ".webm": true, ".mp3": true, ".ogg": true,
+	// documents
+	".pdf": true, ".txt": true, ".csv": true,
+}
+
+// isAsset return true for file allowed for serving as is
+func isAsset(name string) bool {
+	return assetExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// isHidden return true for dotfiles and dot folders like ".git"
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// isIgnored return true for hidden names and folders ignored
+// by options
+func (s *Server) isIgnored(name string) bool {
+	return isHidden(name) || s.ignored[name]
+}
+
+// resolve return path of file inside folder by name from URL.
+// Folder is path inside root folder of server.
+// Name is cleaned, all symlinks are resolved and result must be located
+// inside folder. Hidden and ignored files and folders are denied.
+// Result path is folder joined with cleaned name by slash.
+func (s *Server) resolve(root, name string) (p string, info os.FileInfo, err error) {
+	if strings.Contains(name, "\x00") {
+		err = errorf(http.StatusBadRequest, "Name `%q` with null byte", name)
+		return
+	}
+
+	// clean name
+	name = strings.Replace(name, "\\", "/", -1)
+	name = path.Clean("/" + name)
+	name = strings.TrimPrefix(name, "/")
+	for _, part := range strings.Split(name, "/") {
+		if s.isIgnored(part) {
+			err = errorf(http.StatusForbidden, "Access to hidden file `%s`", name)
+			return
+		}
+	}
+	p = root
+	if name != "" {
+		p = root + "/" + name
+	}
+
+	// resolve symlinks
+	absRoot, err := filepath.Abs(s.file(root))
+	if err != nil {
+		return
+	}
+	if absRoot, err = filepath.EvalSymlinks(absRoot); err != nil {
+		err = fileError(err, "Cannot resolve root folder `%s`", root)
+		return
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(absRoot, filepath.FromSlash(name)))
+	if err != nil {
+		err = fileError(err, "Cannot resolve `%s`", name)
+		return
+	}
+	rel, err := filepath.Rel(absRoot, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		err = errorf(http.StatusForbidden, "File `%s` is outside of root folder", name)
+		return
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if s.isIgnored(part) {
+			err = errorf(http.StatusForbidden, "Access to hidden file `%s`", name)
+			return
+		}
+	}
+
+	if info, err = os.Stat(target); err != nil {
+		err = fileError(err, "Cannot open file `%s`", name)
+		return
+	}
+	return
+}
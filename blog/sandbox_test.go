@@ -0,0 +1,154 @@
+package blog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"root/a.md":          "# A\n",
+		"root/sub/img.png":   "png",
+		"root/.git/config":   "secret",
+		"root/.env":          "secret",
+		"root/sub/.hide.png": "png",
+		"secret.md":          "# Secret\n",
+	})
+	root := filepath.Join(dir, "root")
+	links := map[string]string{
+		"root/link-out.md": filepath.Join(dir, "secret.md"),
+		"root/link-in.md":  filepath.Join(root, "a.md"),
+		"root/link-dir":    dir,
+		"root/link-git":    filepath.Join(root, ".git"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	tcs := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{name: "a.md", path: "root/a.md"},
+		{name: "/a.md", path: "root/a.md"},
+		{name: "./sub/../a.md", path: "root/a.md"},
+		{name: "sub\\img.png", path: "root/sub/img.png"},
+		{name: "", path: "root"},
+		{name: "link-in.md", path: "root/link-in.md"},
+		{name: "../secret.md", status: http.StatusNotFound},
+		{name: "../../../../../../etc/passwd", status: http.StatusNotFound},
+		{name: "..\\secret.md", status: http.StatusNotFound},
+		{name: "not_exist.md", status: http.StatusNotFound},
+		{name: ".git/config", status: http.StatusForbidden},
+		{name: "sub/../.git/config", status: http.StatusForbidden},
+		{name: ".env", status: http.StatusForbidden},
+		{name: "sub/.hide.png", status: http.StatusForbidden},
+		{name: "link-out.md", status: http.StatusForbidden},
+		{name: "link-dir/secret.md", status: http.StatusForbidden},
+		{name: "link-git/config", status: http.StatusForbidden},
+		{name: "a.md\x00.png", status: http.StatusBadRequest},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _, err := s.resolve("root", tc.name)
+			if tc.status != 0 {
+				if err == nil {
+					t.Fatalf("file is resolved: %s", p)
+				}
+				if code := statusCode(err); code != tc.status {
+					t.Errorf("not valid status code: %d != %d. %v", code, tc.status, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != tc.path {
+				t.Errorf("not valid path: %s != %s", p, tc.path)
+			}
+		})
+	}
+}
+
+func TestHostileURL(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	tcs := []struct {
+		handler func(w http.ResponseWriter, r *http.Request)
+		url     string
+		status  int
+	}{
+		{s.articleHandler, "/articles/..%2Fmd%2Fgo.mod", http.StatusNotFound},
+		{s.articleHandler, "/articles/..%2F..%2F..%2Fetc%2Fpasswd", http.StatusNotFound},
+		{s.articleHandler, "/articles/.git%2Fconfig", http.StatusForbidden},
+		{s.articleHandler, "/articles/go.mod", http.StatusForbidden},
+		{s.articleHandler, "/articles/blog", http.StatusForbidden},
+		{s.articleHandler, "/articles/logo.png", http.StatusOK},
+		{s.photosHandler, "/photos/..%2Fmd.go", http.StatusNotFound},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, httptest.NewRequest("GET", tc.url, nil))
+			if w.Code != tc.status {
+				t.Errorf("not valid status code: %d != %d", w.Code, tc.status)
+			}
+		})
+	}
+
+	// articles outside of root folder are not indexed
+	dir, err := ioutil.TempDir("", "md-sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"root/a.md":      "# A\n\npassword\n",
+		"root/.draft.md": "# Hidden\n\npassword\n",
+		"secret.md":      "# Secret\n\npassword\n",
+	})
+	root := filepath.Join(dir, "root")
+	if err := os.Symlink(filepath.Join(dir, "secret.md"), filepath.Join(root, "link.md")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+	sr := newTestServer(t, Options{Root: root, URL: "https://example.com"})
+	defer sr.Close()
+
+	for _, url := range []string{"/", "/search?q=password", "/feed.xml", "/atom.xml"} {
+		t.Run(url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			sr.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("not valid status code: %d", w.Code)
+			}
+			body := w.Body.String()
+			if !strings.Contains(body, "/articles/.%2Fa.md") {
+				t.Errorf("article is not found:\n%s", body)
+			}
+			for _, c := range []string{"Secret", "link.md", "Hidden", "draft.md"} {
+				if strings.Contains(body, c) {
+					t.Errorf("found `%s` in:\n%s", c, body)
+				}
+			}
+		})
+	}
+}
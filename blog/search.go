@@ -0,0 +1,311 @@
+package blog
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// token is word of text with location
+type token struct {
+	// term is word in lower case
+	term string
+	// start, end is byte offsets of word in text
+	start, end int
+}
+
+// tokenize split text to words
+func tokenize(text string) (tokens []token) {
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if 0 <= start {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if 0 <= start {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return
+}
+
+// document is indexed article
+type document struct {
+	article article
+	// text is markdown of article
+	text   string
+	tokens []token
+	// title is terms of article name
+	title map[string]bool
+}
+
+// searchIndex is inverted index of articles
+type searchIndex struct {
+	docs []document
+	// postings is positions of term in tokens of documents
+	postings map[string]map[int][]int
+}
+
+// newSearchIndex return inverted index of all articles from cache
+func newSearchIndex(c *cache) (s *searchIndex, err error) {
+	folders, err := c.list()
+	if err != nil {
+		return
+	}
+	s = &searchIndex{postings: map[string]map[int][]int{}}
+	for _, f := range folders {
+		for _, a := range f.articles {
+			e, err := c.get(a.path)
+			if err != nil || e.err != nil {
+				continue
+			}
+			doc := document{
+				article: e.article,
+				text:    string(e.body),
+				tokens:  tokenize(string(e.body)),
+				title:   map[string]bool{},
+			}
+			for _, t := range tokenize(e.article.name) {
+				doc.title[t.term] = true
+			}
+			id := len(s.docs)
+			for pos, t := range doc.tokens {
+				if s.postings[t.term] == nil {
+					s.postings[t.term] = map[int][]int{}
+				}
+				s.postings[t.term][id] = append(s.postings[t.term][id], pos)
+			}
+			s.docs = append(s.docs, doc)
+		}
+	}
+	return
+}
+
+// query is parsed search query
+type query struct {
+	// terms is single words of query
+	terms []string
+	// phrases is quoted words of query
+	phrases [][]string
+}
+
+// parseQuery parse search query. Words in double quotes are phrase.
+func parseQuery(q string) (qu query) {
+	parts := strings.Split(q, "\"")
+	for i, part := range parts {
+		var terms []string
+		for _, t := range tokenize(part) {
+			terms = append(terms, t.term)
+		}
+		if len(terms) == 0 {
+			continue
+		}
+		if i%2 == 1 && i != len(parts)-1 && 1 < len(terms) {
+			qu.phrases = append(qu.phrases, terms)
+			continue
+		}
+		qu.terms = append(qu.terms, terms...)
+	}
+	return
+}
+
+// empty return true for query without words
+func (qu query) empty() bool {
+	return len(qu.terms) == 0 && len(qu.phrases) == 0
+}
+
+// result is found article
+type result struct {
+	doc   *document
+	score float64
+	// positions is positions of matched tokens
+	positions []int
+}
+
+// search return articles with all words and phrases of query
+// sorted by relevance
+func (s *searchIndex) search(qu query) (results []result) {
+	if qu.empty() {
+		return
+	}
+	for id := range s.docs {
+		r := result{doc: &s.docs[id]}
+		found := true
+		for _, term := range qu.terms {
+			ps := s.postings[term][id]
+			if len(ps) == 0 {
+				found = false
+				break
+			}
+			r.score += s.weight(term, len(ps))
+			if r.doc.title[term] {
+				r.score += 2
+			}
+			r.positions = append(r.positions, ps...)
+		}
+		for _, phrase := range qu.phrases {
+			if !found {
+				break
+			}
+			ps := s.phrase(id, phrase)
+			if len(ps) == 0 {
+				found = false
+				break
+			}
+			for _, term := range phrase {
+				r.score += s.weight(term, len(ps))
+			}
+			// phrase is more relevant then separate words
+			r.score *= 1.5
+			for _, p := range ps {
+				for i := range phrase {
+					r.positions = append(r.positions, p+i)
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		sort.Ints(r.positions)
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].doc.article.path < results[j].doc.article.path
+	})
+	return
+}
+
+// weight return tf-idf weight of term
+func (s *searchIndex) weight(term string, tf int) float64 {
+	df := len(s.postings[term])
+	return float64(tf) * math.Log(1+float64(len(s.docs))/float64(df))
+}
+
+// phrase return positions of phrase in document
+func (s *searchIndex) phrase(id int, phrase []string) (ps []int) {
+	tokens := s.docs[id].tokens
+	for _, p := range s.postings[phrase[0]][id] {
+		if len(tokens) < p+len(phrase) {
+			continue
+		}
+		found := true
+		for i := 1; i < len(phrase); i++ {
+			if tokens[p+i].term != phrase[i] {
+				found = false
+				break
+			}
+		}
+		if found {
+			ps = append(ps, p)
+		}
+	}
+	return
+}
+
+// snippet return html part of text around first matched token
+// with highlighted matches
+func (r result) snippet() string {
+	const around = 12
+	tokens := r.doc.tokens
+	if len(r.positions) == 0 || len(tokens) == 0 {
+		return ""
+	}
+	first := r.positions[0]
+	from, to := first-around, first+around
+	if from < 0 {
+		from = 0
+	}
+	if len(tokens) <= to {
+		to = len(tokens) - 1
+	}
+
+	matched := map[int]bool{}
+	for _, p := range r.positions {
+		matched[p] = true
+	}
+
+	var out string
+	if 0 < from {
+		out += "&hellip; "
+	}
+	last := tokens[from].start
+	for p := from; p <= to; p++ {
+		if !matched[p] {
+			continue
+		}
+		out += html.EscapeString(r.doc.text[last:tokens[p].start])
+		out += "<mark>" + html.EscapeString(r.doc.text[tokens[p].start:tokens[p].end]) + "</mark>"
+		last = tokens[p].end
+	}
+	out += html.EscapeString(r.doc.text[last:tokens[to].end])
+	if to < len(tokens)-1 {
+		out += " &hellip;"
+	}
+	return out
+}
+
+// searchForm return html form for search articles
+func searchForm(action, q string) string {
+	return fmt.Sprintf(`<form action="%s"><input type="search" name="q" value="%s" placeholder="Search"> <input type="submit" value="Search"></form>`,
+		html.EscapeString(action), html.EscapeString(q))
+}
+
+// searchHandler generate web page with found articles
+func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try search: %v. %v", r.URL.RawQuery, err)
+			}
+		}()
+		q := r.URL.Query().Get("q")
+		index, err := s.cache.search()
+		if err != nil {
+			return
+		}
+		l := s.links()
+
+		var body string
+		body += fmt.Sprintf("<p><a href=\"%s\">Main page</a></p>\n\n", l.main())
+		body += searchForm(l.search(), q) + "\n\n"
+
+		var results []result
+		for _, res := range index.search(parseQuery(q)) {
+			if res.doc.article.isDraft() && !s.opts.Drafts {
+				continue
+			}
+			results = append(results, res)
+		}
+		if q != "" {
+			body += fmt.Sprintf("<h1>Found %d articles</h1>\n\n", len(results))
+		}
+		for _, res := range results {
+			a := res.doc.article
+			body += fmt.Sprintf("<p><a href=\"%s\">%s</a><br>\n%s</p>\n\n",
+				html.EscapeString(l.article(a.path)),
+				html.EscapeString(a.name),
+				res.snippet())
+		}
+		out, err := s.page(newPage("search", "Search: "+q, []byte(body), l))
+		if err != nil {
+			return
+		}
+		w.Write(out)
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
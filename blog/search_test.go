@@ -0,0 +1,94 @@
+package blog
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tcs := []struct {
+		q     string
+		query query
+	}{
+		{q: "", query: query{}},
+		{q: "Go  Web", query: query{terms: []string{"go", "web"}}},
+		{
+			q: `"static site" build`,
+			query: query{
+				terms:   []string{"build"},
+				phrases: [][]string{{"static", "site"}},
+			},
+		},
+		// phrase with one word
+		{q: `"go"`, query: query{terms: []string{"go"}}},
+		// not closed quote
+		{q: `"static site`, query: query{terms: []string{"static", "site"}}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.q, func(t *testing.T) {
+			qu := parseQuery(tc.q)
+			if !reflect.DeepEqual(qu, tc.query) {
+				t.Errorf("not same: %#v != %#v", qu, tc.query)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"go.md":     "# Go language\n\nGo is programming language. Static site is generated by Go.\n",
+		"site.md":   "# Static site\n\nSite is static, but language of site is not important.\n",
+		"other.md":  "# Other\n\nNothing <interesting> here.\n",
+		"sub/go.md": "# Notes\n\nsite go static\n",
+	})
+
+	srv := newTestServer(t, Options{Root: dir})
+	defer srv.Close()
+	s, err := newSearchIndex(srv.cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		q       string
+		names   string
+		snippet string
+	}{
+		{q: "", names: ""},
+		{q: "absent", names: ""},
+		{q: "language", names: "Go language,Static site"},
+		{q: "STATIC site", names: "Static site,Go language,Notes"},
+		{q: `"static site"`, names: "Go language,Static site"},
+		{q: `"site go static"`, names: "Notes"},
+		{q: `go "static site"`, names: "Go language"},
+		{
+			q:       "interesting",
+			names:   "Other",
+			snippet: "Other\n\nNothing &lt;<mark>interesting</mark>&gt; here",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.q, func(t *testing.T) {
+			results := s.search(parseQuery(tc.q))
+			var names []string
+			for _, r := range results {
+				names = append(names, r.doc.article.name)
+			}
+			if n := strings.Join(names, ","); n != tc.names {
+				t.Errorf("not valid results: `%s` != `%s`", n, tc.names)
+			}
+			if tc.snippet != "" && results[0].snippet() != tc.snippet {
+				t.Errorf("not valid snippet: `%s` != `%s`", results[0].snippet(), tc.snippet)
+			}
+		})
+	}
+}
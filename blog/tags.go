@@ -0,0 +1,123 @@
+package blog
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// tagName return normalized name of tag
+func tagName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Replace(name, "/", "-", -1)
+	return name
+}
+
+// tags return articles of all tags. Draft articles are ignored
+// if drafts is not set.
+func tags(c *cache, drafts bool) (ts map[string][]article, err error) {
+	folders, err := c.list()
+	if err != nil {
+		return
+	}
+	ts = map[string][]article{}
+	for _, f := range folders {
+		for _, a := range f.articles {
+			if a.isDraft() && !drafts {
+				continue
+			}
+			used := map[string]bool{}
+			for _, tag := range a.meta.Tags {
+				name := tagName(tag)
+				if name == "" || used[name] {
+					continue
+				}
+				used[name] = true
+				ts[name] = append(ts[name], a)
+			}
+		}
+	}
+	for name := range ts {
+		sortArticles(ts[name])
+	}
+	return
+}
+
+// tagNames return sorted names of tags
+func tagNames(ts map[string][]article) (names []string) {
+	for name := range ts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// tagsPage generate markdown of page with list of all tags
+func tagsPage(ts map[string][]article, l links) string {
+	var content string
+	content += fmt.Sprintf("[Main page](%s)\n\n", l.main())
+	content += "# Tags\n\n"
+	for _, name := range tagNames(ts) {
+		content += fmt.Sprintf("* [%s](%s) (%d)\n", name, l.tag(name), len(ts[name]))
+	}
+	return content
+}
+
+// tagPage generate markdown of page with articles of tag
+func tagPage(name string, articles []article, l links) string {
+	var content string
+	content += fmt.Sprintf("[Main page](%s)\n\n", l.main())
+	content += fmt.Sprintf("[All tags](%s)\n\n", l.tag(""))
+	content += fmt.Sprintf("# Tag: %s\n\n", name)
+	for _, a := range articles {
+		content += fmt.Sprintf("[%s](%s)\n\n", a.name, l.article(a.path))
+		if !a.meta.Date.IsZero() {
+			content += fmt.Sprintf("%s\n\n", a.meta.Date.Format("2006-01-02"))
+		}
+	}
+	return content
+}
+
+// tagsHandler generate web page with all tags or articles of tag
+func (s *Server) tagsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try open page in tags: %v. %v", r.URL.Path, err)
+			}
+		}()
+		ts, err := tags(s.cache, s.opts.Drafts)
+		if err != nil {
+			return
+		}
+		l := s.links()
+
+		// path of URL is already unescaped
+		name := tagName(strings.TrimPrefix(r.URL.Path, "/tags/"))
+		if name == "" {
+			html := s.render([]byte(tagsPage(ts, l)))
+			var out []byte
+			if out, err = s.page(newPage("tags", "Tags", html, l)); err != nil {
+				return
+			}
+			w.Write(out)
+			return
+		}
+
+		articles, ok := ts[name]
+		if !ok {
+			err = errorf(http.StatusNotFound, "Tag `%s` is not found", name)
+			return
+		}
+		html := s.render([]byte(tagPage(name, articles, l)))
+		out, err := s.page(newPage("tags", "Tag: "+name, html, l))
+		if err != nil {
+			return
+		}
+		w.Write(out)
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
@@ -0,0 +1,132 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pageLink is link in navigation of page
+type pageLink struct {
+	Name string
+	URL  string
+}
+
+// pageData is data of web page for layout templates
+type pageData struct {
+	// Kind is kind of page: "index", "article", "gallery", "photo", "tags", "search"
+	Kind string
+	// Title is title of page
+	Title string
+	// Content is rendered html of page
+	Content template.HTML
+	// Breadcrumbs is links from main page to current page
+	Breadcrumbs []pageLink
+	// Navigation is links to main pages of blog
+	Navigation []pageLink
+	// Meta is metadata of article
+	Meta meta
+	// TOC is rendered table of contents of article
+	TOC template.HTML
+}
+
+// newPage return data of page with default navigation
+func newPage(kind, title string, content []byte, l links) pageData {
+	data := pageData{
+		Kind:    kind,
+		Title:   title,
+		Content: template.HTML(content),
+		Navigation: []pageLink{
+			{Name: "Main page", URL: l.main()},
+			{Name: "Tags", URL: l.tag("")},
+		},
+	}
+	if action := l.search(); action != "" {
+		data.Navigation = append(data.Navigation, pageLink{Name: "Search", URL: action})
+	}
+	data.Breadcrumbs = []pageLink{{Name: "Main page", URL: l.main()}}
+	if kind != "index" {
+		data.Breadcrumbs = append(data.Breadcrumbs, pageLink{Name: title})
+	}
+	return data
+}
+
+// themeKinds is kinds of pages with specific templates in theme
+var themeKinds = []string{"index", "article", "gallery"}
+
+// themeFuncs is functions available in theme templates
+var themeFuncs = template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	},
+	"highlightCSS": func() template.CSS {
+		return template.CSS(highlightCSS)
+	},
+}
+
+// loadTheme parse layout templates from theme folder.
+// File "base.html" is required and used for all pages. Files
+// "index.html", "article.html", "gallery.html" are optional and
+// parsed together with "base.html", so they can redefine blocks
+// of base template.
+func loadTheme(dir string) (t map[string]*template.Template, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("Cannot load theme from `%s`: %v", dir, err)
+		}
+	}()
+	base := filepath.Join(dir, "base.html")
+	t = map[string]*template.Template{}
+	t["base"], err = template.New("base.html").Funcs(themeFuncs).ParseFiles(base)
+	if err != nil {
+		return
+	}
+	for _, kind := range themeKinds {
+		name := filepath.Join(dir, kind+".html")
+		if _, errS := os.Stat(name); errS != nil {
+			continue
+		}
+		t[kind], err = template.New("base.html").Funcs(themeFuncs).ParseFiles(base, name)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// page return html page of web server. Script for live reload is added
+// at the end of body.
+func (s *Server) page(data pageData) (out []byte, err error) {
+	out, err = s.themePage(data)
+	if err == nil && s.bodyEnd != "" {
+		out = []byte(strings.Replace(string(out), "</body>", s.bodyEnd+"</body>", 1))
+	}
+	return
+}
+
+// themePage return html page generated by theme or by built-in template
+func (s *Server) themePage(data pageData) (out []byte, err error) {
+	if s.theme == nil {
+		// table of contents at the top of page
+		out = []byte(fmt.Sprintf(tmpl, html.EscapeString(data.Title), data.TOC+data.Content))
+	} else {
+		t, ok := s.theme[data.Kind]
+		if !ok {
+			t = s.theme["base"]
+		}
+		var buf bytes.Buffer
+		if err = t.Execute(&buf, data); err != nil {
+			return
+		}
+		out = buf.Bytes()
+	}
+	return
+}
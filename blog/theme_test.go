@@ -0,0 +1,71 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTheme(t *testing.T) {
+	s := newTestServer(t, Options{Theme: "testdata/theme"})
+	defer s.Close()
+
+	if _, ok := s.theme["article"]; !ok {
+		t.Fatalf("article template is not loaded")
+	}
+	if _, ok := s.theme["gallery"]; ok {
+		t.Fatalf("gallery template is loaded")
+	}
+
+	tcs := []struct {
+		name     string
+		handler  func(w http.ResponseWriter, r *http.Request)
+		url      string
+		contains []string
+	}{
+		{
+			name:    "article",
+			handler: s.articleHandler,
+			url:     "/article/blog/testdata/frontmatter.md",
+			contains: []string{
+				"<title>Article with front matter</title>",
+				`<a href="/">Main page</a> / Article with front matter`,
+				`<main class="article">`,
+				`<p class="author">Konstantin</p>`,
+				`<p class="date">2019-05-17</p>`,
+				`<h1 id="header-of-article">Header of article</h1>`,
+				"Theme footer",
+			},
+		},
+		{
+			name:    "index",
+			handler: s.mainHandler,
+			url:     "/",
+			contains: []string{
+				"<title>List of articles</title>",
+				`<a href="/tags/">Tags</a> <a href="/search">Search</a>`,
+				`<main class="index">`,
+				"Theme footer",
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, httptest.NewRequest("GET", tc.url, nil))
+			body := w.Body.String()
+			for _, c := range tc.contains {
+				if !strings.Contains(body, c) {
+					t.Errorf("cannot find `%s` in:\n%s", c, body)
+				}
+			}
+		})
+	}
+}
+
+func TestThemeNotExist(t *testing.T) {
+	if _, err := loadTheme("testdata/not_exist_theme"); err == nil {
+		t.Fatalf("theme is loaded")
+	}
+}
@@ -0,0 +1,202 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // decoder of gif thumbnails
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// thumbs is prefix of thumbnails addresses
+const thumbs string = "thumbs"
+
+// thumbSize is maximal width and height of thumbnail
+const thumbSize int = 300
+
+// thumbExtensions is extensions of images with thumbnails
+var thumbExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+}
+
+// hasThumb return true for images with thumbnail
+func hasThumb(name string) bool {
+	return thumbExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// thumbEntry is generated thumbnail of image
+type thumbEntry struct {
+	modTime time.Time
+	size    int64
+
+	// data is encoded thumbnail
+	data []byte
+	// contentType is MIME type of data
+	contentType string
+}
+
+// thumbCache is in-memory cache of thumbnails.
+// Entries are checked by modification time and size of image.
+type thumbCache struct {
+	mu      sync.Mutex
+	entries map[string]*thumbEntry
+}
+
+// get return actual thumbnail of image file
+func (c *thumbCache) get(path string) (e *thumbEntry, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e, nil
+	}
+
+	e = &thumbEntry{modTime: info.ModTime(), size: info.Size()}
+	if e.data, e.contentType, err = thumbnail(path); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[path] = e
+	c.mu.Unlock()
+	return
+}
+
+// thumbnail return encoded thumbnail of image file. JPEG images are
+// rotated by EXIF orientation and encoded as JPEG, other images are
+// encoded as PNG with transparency.
+func thumbnail(path string) (data []byte, contentType string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	src, format, err := image.Decode(f)
+	if err != nil {
+		err = fmt.Errorf("Cannot decode image `%s`: %v", path, err)
+		return
+	}
+	dst := resize(src, thumbSize)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+		if e, errE := readEXIF(f); errE == nil {
+			dst = orient(dst, e.orientation)
+		}
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		contentType = "image/png"
+		err = png.Encode(&buf, dst)
+	}
+	return buf.Bytes(), contentType, err
+}
+
+// resize return image scaled down to fit in square with side size.
+// Each pixel of result is average of source pixels in the same area.
+func resize(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+	tw, th := size, h*size/w
+	if w < h {
+		tw, th = w*size/h, size
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	// source with fast access to pixels
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	} else {
+		rgba = rgba.SubImage(b).(*image.RGBA)
+	}
+	rb := rgba.Bounds()
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, (y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, (x+1)*w/tw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := rgba.PixOffset(rb.Min.X+x0, rb.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(rgba.Pix[i])
+					g += uint64(rgba.Pix[i+1])
+					bl += uint64(rgba.Pix[i+2])
+					a += uint64(rgba.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(bl / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// thumbHandler generate thumbnail of image in photos folder
+func (s *Server) thumbHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try open thumbnail: %v. %v", r.URL.Path, err)
+			}
+		}()
+		name := strings.TrimPrefix(r.URL.Path, "/"+thumbs+"/")
+		f, info, err := s.resolve(s.opts.Photos, name)
+		if err != nil {
+			return
+		}
+		if info.IsDir() || !hasThumb(f) {
+			return errorf(http.StatusNotFound, "File `%s` has not thumbnail", f)
+		}
+		e, err := s.thumbs.get(s.file(f))
+		if err != nil {
+			// image is not decoded, so original file is used
+			if rec, ok := w.(errorRecorder); ok {
+				rec.recordError(err)
+			}
+			http.ServeFile(w, r, s.file(f))
+			return nil
+		}
+		w.Header().Set("Content-Type", e.contentType)
+		http.ServeContent(w, r, f, e.modTime, bytes.NewReader(e.data))
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
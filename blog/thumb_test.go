@@ -0,0 +1,176 @@
+package blog
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createImage write image with size in file
+func createImage(t *testing.T, filename string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if strings.HasSuffix(filename, ".png") {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResize(t *testing.T) {
+	tcs := []struct {
+		w, h   int
+		expect image.Point
+	}{
+		{1200, 600, image.Pt(300, 150)},
+		{600, 1200, image.Pt(150, 300)},
+		{200, 100, image.Pt(200, 100)},
+		{3000, 5, image.Pt(300, 1)},
+	}
+	for _, tc := range tcs {
+		src := image.NewRGBA(image.Rect(0, 0, tc.w, tc.h))
+		if size := resize(src, thumbSize).Bounds().Size(); size != tc.expect {
+			t.Errorf("not valid size of %dx%d: %v", tc.w, tc.h, size)
+		}
+	}
+
+	// average of pixels
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.RGBA{R: 200, A: 255})
+			} else {
+				src.Set(x, y, color.RGBA{B: 100, A: 255})
+			}
+		}
+	}
+	dst := resize(src, 2)
+	if c := dst.At(1, 0); c != (color.RGBA{R: 100, B: 50, A: 255}) {
+		t.Errorf("not valid average color: %v", c)
+	}
+}
+
+func TestThumbHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-thumb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createImage(t, filepath.Join(dir, "photos", "album", "big.jpg"), 900, 600)
+	createImage(t, filepath.Join(dir, "photos", "album", "big.png"), 600, 900)
+	createTree(t, dir, map[string]string{
+		"photos/album/broken.jpg": "not image",
+		"photos/album/text.txt":   "text",
+	})
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	tcs := []struct {
+		url         string
+		status      int
+		contentType string
+		size        image.Point
+	}{
+		{"/thumbs/album/big.jpg", http.StatusOK, "image/jpeg", image.Pt(300, 200)},
+		{"/thumbs/album/big.png", http.StatusOK, "image/png", image.Pt(200, 300)},
+		{"/thumbs/album/broken.jpg", http.StatusOK, "image/jpeg", image.Point{}},
+		{"/thumbs/album/text.txt", http.StatusNotFound, "", image.Point{}},
+		{"/thumbs/album", http.StatusNotFound, "", image.Point{}},
+		{"/thumbs/album/not-exist.jpg", http.StatusNotFound, "", image.Point{}},
+		{"/thumbs/../md.go", http.StatusNotFound, "", image.Point{}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.thumbHandler(w, httptest.NewRequest("GET", tc.url, nil))
+			if w.Code != tc.status {
+				t.Fatalf("not valid status: %d", w.Code)
+			}
+			if tc.contentType == "" {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tc.contentType {
+				t.Errorf("not valid content type: %s", ct)
+			}
+			if tc.size == (image.Point{}) {
+				// original file
+				if w.Body.String() != "not image" {
+					t.Errorf("original file is not served")
+				}
+				return
+			}
+			img, _, err := image.Decode(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := img.Bounds().Size(); size != tc.size {
+				t.Errorf("not valid size: %v", size)
+			}
+		})
+	}
+
+	// thumbnail is cached
+	f := filepath.Join(dir, "photos", "album", "big.jpg")
+	e, err := s.thumbs.get(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e2, _ := s.thumbs.get(f); e != e2 {
+		t.Errorf("thumbnail is not cached")
+	}
+}
+
+func TestGalleryThumbs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-thumb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	createTree(t, dir, map[string]string{
+		"one.jpg":   "jpg",
+		"movie.mp4": "mp4",
+	})
+	ps, err := (&photoCache{entries: map[string]*photoEntry{}}).readPhotos(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+	content := s.albumPage(album{path: "album", photos: ps}, s.links())
+	for _, expect := range []string{
+		"[![one.jpg](/thumbs/album/one.jpg)](/view/album/one.jpg)",
+		"![movie.mp4](/photos/album/movie.mp4)",
+	} {
+		if !strings.Contains(content, expect) {
+			t.Errorf("cannot find `%s` in:\n%s", expect, content)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package blog
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/russross/blackfriday"
+	"github.com/shurcooL/sanitized_anchor_name"
+)
+
+// heading is heading of article
+type heading struct {
+	level int
+	text  string
+	id    string
+}
+
+// headings return all headings of markdown AST. Identifiers of
+// headings are changed to unique, same as in html renderer, and
+// placeholders of formulas are removed from identifiers. Identifiers
+// are created from text of headings, if parser does not create them.
+func headings(ast *blackfriday.Node) (hs []heading) {
+	ids := map[string]int{}
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.Heading || node.IsTitleblock {
+			return blackfriday.GoToNext
+		}
+		if node.HeadingID == "" {
+			node.HeadingID = sanitized_anchor_name.Create(nodeText(node))
+		}
+		if node.HeadingID == "" {
+			return blackfriday.GoToNext
+		}
+		node.HeadingID = uniqueID(ids, mathID(node.HeadingID))
+		hs = append(hs, heading{
+			level: node.Level,
+			text:  nodeText(node),
+			id:    node.HeadingID,
+		})
+		return blackfriday.SkipChildren
+	})
+	return
+}
+
+// uniqueID return unique identifier by the same algorithm as
+// blackfriday html renderer
+func uniqueID(ids map[string]int, id string) string {
+	for count, found := ids[id]; found; count, found = ids[id] {
+		tmp := fmt.Sprintf("%s-%d", id, count+1)
+		if _, tmpFound := ids[tmp]; !tmpFound {
+			ids[id] = count + 1
+			id = tmp
+		} else {
+			id = id + "-1"
+		}
+	}
+	if _, found := ids[id]; !found {
+		ids[id] = 0
+	}
+	return id
+}
+
+// nodeText return text of node and all children
+func nodeText(node *blackfriday.Node) string {
+	var text string
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (n.Type == blackfriday.Text || n.Type == blackfriday.Code) {
+			text += string(n.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.TrimSpace(text)
+}
+
+// tocHTML return html of table of contents with nested lists
+func tocHTML(hs []heading) []byte {
+	if len(hs) < 2 {
+		return nil
+	}
+	min := hs[0].level
+	for _, h := range hs {
+		if h.level < min {
+			min = h.level
+		}
+	}
+
+	var out string
+	out += "<nav class=\"toc\">\n"
+	level := min - 1
+	for i, h := range hs {
+		switch {
+		case level < h.level:
+			for ; level < h.level; level++ {
+				out += "<ul>\n<li>"
+			}
+		default:
+			for ; h.level < level; level-- {
+				out += "</li>\n</ul>\n"
+			}
+			if 0 < i {
+				out += "</li>\n<li>"
+			}
+		}
+		out += fmt.Sprintf("<a href=\"#%s\">%s</a>", html.EscapeString(h.id), html.EscapeString(h.text))
+	}
+	for ; min <= level; level-- {
+		out += "</li>\n</ul>\n"
+	}
+	out += "</nav>\n"
+	return []byte(out)
+}
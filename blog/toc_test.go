@@ -0,0 +1,86 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/russross/blackfriday"
+)
+
+func TestTOC(t *testing.T) {
+	tcs := []struct {
+		name     string
+		markdown string
+		meta     meta
+		toc      string
+		html     string
+	}{
+		{
+			name:     "one heading",
+			markdown: "# Header\n\ntext",
+			toc:      "",
+			html:     `<h1 id="header">Header</h1>`,
+		},
+		{
+			name:     "disabled",
+			markdown: "# A\n\n# B\n",
+			meta:     meta{NoTOC: true},
+			toc:      "",
+			html:     `<h1 id="b">B</h1>`,
+		},
+		{
+			name:     "nested",
+			markdown: "# A\n\n## B `code`\n\n### C\n\n## D\n\n# E\n",
+			toc: `<nav class="toc">
+<ul>
+<li><a href="#a">A</a><ul>
+<li><a href="#b-code">B code</a><ul>
+<li><a href="#c">C</a></li>
+</ul>
+</li>
+<li><a href="#d">D</a></li>
+</ul>
+</li>
+<li><a href="#e">E</a></li>
+</ul>
+</nav>
+`,
+			html: `<h2 id="b-code">B <code>code</code></h2>`,
+		},
+		{
+			name:     "same headings",
+			markdown: "## Same\n\n## Same\n\n## Same & more\n",
+			toc: `<nav class="toc">
+<ul>
+<li><a href="#same">Same</a></li>
+<li><a href="#same-1">Same</a></li>
+<li><a href="#same-more">Same &amp; more</a></li>
+</ul>
+</nav>
+`,
+			html: `<h2 id="same-1">Same</h2>`,
+		},
+	}
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			html, toc := s.renderArticle(article{meta: tc.meta}, []byte(tc.markdown), s.links())
+			if string(toc) != tc.toc {
+				t.Errorf("not valid toc:\n%s", ShowDiff(string(toc), tc.toc))
+			}
+			if !strings.Contains(string(html), tc.html) {
+				t.Errorf("cannot find `%s` in:\n%s", tc.html, string(html))
+			}
+		})
+	}
+
+	// identifiers are created without extension of parser
+	s.opts.Extensions = blackfriday.CommonExtensions
+	html, toc := s.renderArticle(article{}, []byte("# A\n\n## B `code`\n"), s.links())
+	if !strings.Contains(string(toc), `<a href="#b-code">B code</a>`) ||
+		!strings.Contains(string(html), `<h2 id="b-code">B <code>code</code></h2>`) {
+		t.Errorf("not valid identifiers of headings:\n%s\n%s", string(toc), string(html))
+	}
+}
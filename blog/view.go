@@ -0,0 +1,129 @@
+package blog
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// views is prefix of addresses of photo view pages
+const views string = "view"
+
+// viewScript change photo by arrow keys
+const viewScript string = `<script>
+document.addEventListener("keydown", function(e) {
+	var rel = {ArrowLeft: "prev", ArrowRight: "next", Escape: "up"}[e.key];
+	var a = rel && document.querySelector("a[rel=" + rel + "]");
+	if (a) { location.href = a.href; }
+});
+</script>`
+
+// viewPage generate markdown of photo view page with link to previous
+// and next photo of album. Links have access keys: "p" for previous,
+// "n" for next and "u" for album, also arrow keys are supported.
+func viewPage(a album, i int, l links) string {
+	p := a.photos[i]
+	var content string
+	bs := albumBreadcrumbs(a.path, l)
+	for _, b := range bs {
+		content += fmt.Sprintf("[%s](%s) / ", b.Name, b.URL)
+	}
+	content += p.name + "\n\n"
+
+	// navigation
+	var nav []string
+	if 0 < i {
+		nav = append(nav, fmt.Sprintf(`<a href="%s" rel="prev" accesskey="p">&larr; Previous</a>`,
+			html.EscapeString(l.view(a.path, a.photos[i-1].name))))
+	}
+	nav = append(nav, fmt.Sprintf(`<a href="%s" rel="up" accesskey="u">Album</a>`,
+		html.EscapeString(l.album(a.path))))
+	if i < len(a.photos)-1 {
+		nav = append(nav, fmt.Sprintf(`<a href="%s" rel="next" accesskey="n">Next &rarr;</a>`,
+			html.EscapeString(l.view(a.path, a.photos[i+1].name))))
+	}
+	content += "<p class=\"photo-nav\">" + strings.Join(nav, " | ") + "</p>\n\n"
+
+	content += fmt.Sprintf("![%s](%s)\n\n", p.name, l.photo(a.path, p.name))
+
+	// caption
+	var caption []string
+	if !p.date.IsZero() {
+		caption = append(caption, p.date.Format("2006-01-02 15:04"))
+	}
+	if p.camera != "" {
+		caption = append(caption, p.camera)
+	}
+	if 0 < p.width && 0 < p.height {
+		caption = append(caption, fmt.Sprintf("%dx%d", p.width, p.height))
+	}
+	if 0 < len(caption) {
+		content += fmt.Sprintf("*%s*\n\n", strings.Join(caption, ", "))
+	}
+
+	content += fmt.Sprintf(`<p><a href="%s" download>Download original</a></p>`+"\n\n",
+		html.EscapeString(l.photo(a.path, p.name)))
+	content += viewScript + "\n"
+	return content
+}
+
+// viewPageData return data of photo view page for layout templates
+func viewPageData(a album, i int, content []byte, l links) pageData {
+	name := a.photos[i].name
+	data := newPage("photo", name, content, l)
+	data.Breadcrumbs = append(albumBreadcrumbs(a.path, l), pageLink{Name: name})
+	return data
+}
+
+// findPhoto return index of photo in album by name
+func findPhoto(a album, name string) (int, bool) {
+	for i, p := range a.photos {
+		if p.name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// viewHandler generate view page of photo in album
+func (s *Server) viewHandler(w http.ResponseWriter, r *http.Request) {
+	if err := func() (err error) {
+		defer func() {
+			if err != nil {
+				err = errorf(statusCode(err), "Try open photo: %v. %v", r.URL.Path, err)
+			}
+		}()
+		name := strings.TrimPrefix(r.URL.Path, "/"+views+"/")
+		f, info, err := s.resolve(s.opts.Photos, name)
+		if err != nil {
+			return
+		}
+		if info.IsDir() || !isAsset(f) {
+			return errorf(http.StatusNotFound, "File `%s` is not photo", f)
+		}
+		name = strings.TrimPrefix(f, s.opts.Photos+"/")
+		albumPath := path.Dir(name)
+		if albumPath == "." {
+			albumPath = ""
+		}
+		a, err := s.readAlbum(albumPath)
+		if err != nil {
+			return fileError(err, "Cannot read album `%s`", albumPath)
+		}
+		i, ok := findPhoto(a, path.Base(name))
+		if !ok {
+			return errorf(http.StatusNotFound, "Photo `%s` is not found in album", name)
+		}
+		l := s.links()
+		out, err := s.page(viewPageData(a, i, s.render([]byte(viewPage(a, i, l))), l))
+		if err != nil {
+			return
+		}
+		w.Write(out)
+		return
+	}(); err != nil {
+		s.writeError(w, r, err)
+	}
+}
@@ -0,0 +1,105 @@
+package blog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestViewHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	createTree(t, dir, map[string]string{
+		"photos/album/a.jpg":     "jpg",
+		"photos/album/b.png":     "png",
+		"photos/album/c.jpg":     "jpg",
+		"photos/album/README.md": "album",
+	})
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	tcs := []struct {
+		url         string
+		status      int
+		contains    []string
+		notContains []string
+	}{
+		{
+			url:    "/view/album/a.jpg",
+			status: http.StatusOK,
+			contains: []string{
+				`<title>a.jpg</title>`,
+				`<a href="/photos/album">album</a> / a.jpg`,
+				`<a href="/photos/album" rel="up" accesskey="u">Album</a>`,
+				`<a href="/view/album/b.png" rel="next" accesskey="n">Next &rarr;</a>`,
+				`<img src="/photos/album/a.jpg" alt="a.jpg"`,
+				`<a href="/photos/album/a.jpg" download>Download original</a>`,
+				`ArrowRight`,
+			},
+			notContains: []string{`rel="prev"`},
+		},
+		{
+			url:    "/view/album/b.png",
+			status: http.StatusOK,
+			contains: []string{
+				`<a href="/view/album/a.jpg" rel="prev" accesskey="p">&larr; Previous</a>`,
+				`<a href="/view/album/c.jpg" rel="next" accesskey="n">Next &rarr;</a>`,
+			},
+		},
+		{
+			url:         "/view/album/c.jpg",
+			status:      http.StatusOK,
+			contains:    []string{`rel="prev"`},
+			notContains: []string{`rel="next"`},
+		},
+		{url: "/view/album", status: http.StatusNotFound},
+		{url: "/view/album/README.md", status: http.StatusNotFound},
+		{url: "/view/album/not-exist.jpg", status: http.StatusNotFound},
+		{url: "/view/../md.go", status: http.StatusNotFound},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.viewHandler(w, httptest.NewRequest("GET", tc.url, nil))
+			if w.Code != tc.status {
+				t.Fatalf("not valid status: %d", w.Code)
+			}
+			for _, c := range tc.contains {
+				if !strings.Contains(w.Body.String(), c) {
+					t.Errorf("cannot find `%s` in:\n%s", c, w.Body.String())
+				}
+			}
+			for _, c := range tc.notContains {
+				if strings.Contains(w.Body.String(), c) {
+					t.Errorf("found `%s` in:\n%s", c, w.Body.String())
+				}
+			}
+		})
+	}
+
+	// static pages of photos
+	if err := s.Build(filepath.Join(dir, "public")); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "public", "view", "album", "b.png.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{
+		`href="../../view/album/a.jpg.html" rel="prev"`,
+		`href="../../view/album/c.jpg.html" rel="next"`,
+		`href="../../photos/album/index.html" rel="up"`,
+		`src="../../photos/album/b.png"`,
+	} {
+		if !strings.Contains(string(content), c) {
+			t.Errorf("cannot find `%s` in:\n%s", c, string(content))
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package blog
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// reload is address of server-sent events with page reload
+const reload string = "/reload"
+
+// reloadScript reload page after changes of files on disk
+var reloadScript = `
+		<script>
+			new EventSource("` + reload + `").addEventListener("reload", function() {
+				location.reload();
+			});
+		</script>
+	`
+
+// fileState is state of file on disk
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// isChanged return true if files are added, removed or changed
+func isChanged(old, state map[string]fileState) bool {
+	if len(state) != len(old) {
+		return true
+	}
+	for path, s := range state {
+		if o, ok := old[path]; !ok || !o.modTime.Equal(s.modTime) || o.size != s.size {
+			return true
+		}
+	}
+	return false
+}
+
+// watcher check changes of files by polling and notify clients
+type watcher struct {
+	root string
+	// ignore return true for names of ignored files and folders
+	ignore func(name string) bool
+
+	// onChange is called after each change of files
+	onChange func()
+	// errorLog is logger of errors of checks
+	errorLog *log.Logger
+
+	mu      sync.Mutex
+	state   map[string]fileState
+	clients map[chan struct{}]bool
+
+	// done is closed for stop all streams of events
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newWatcher return watcher of files inside root folder
+func newWatcher(root string, ignore func(name string) bool) (*watcher, error) {
+	w := &watcher{
+		root:    root,
+		ignore:  ignore,
+		clients: map[chan struct{}]bool{},
+		done:    make(chan struct{}),
+	}
+	state, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.state = state
+	return w, nil
+}
+
+// scan return state of all files inside root folder.
+// Ignored files and folders are skipped.
+func (w *watcher) scan() (state map[string]fileState, err error) {
+	state = map[string]fileState{}
+	err = filepath.Walk(w.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// file is removed during walking
+				return nil
+			}
+			return err
+		}
+		if path != w.root && w.ignore(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		state[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return
+}
+
+// check compare files on disk with last state and notify clients
+// about changes. Return true if any file is changed.
+func (w *watcher) check() (changed bool, err error) {
+	state, err := w.scan()
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed = isChanged(w.state, state)
+	w.state = state
+	if !changed {
+		return
+	}
+	if w.onChange != nil {
+		w.onChange()
+	}
+	for ch := range w.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// client is already notified
+		}
+	}
+	return
+}
+
+// run check changes of files with interval until watcher is closed
+func (w *watcher) run(interval time.Duration) {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-time.After(interval):
+		}
+		if _, err := w.check(); err != nil {
+			w.errorLog.Printf("watcher error : %v", err)
+		}
+	}
+}
+
+// subscribe return channel with notifications about changes
+func (w *watcher) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	w.mu.Lock()
+	w.clients[ch] = true
+	w.mu.Unlock()
+	return ch
+}
+
+// unsubscribe remove channel from notifications
+func (w *watcher) unsubscribe(ch chan struct{}) {
+	w.mu.Lock()
+	delete(w.clients, ch)
+	w.mu.Unlock()
+}
+
+// close stop all streams of events, so that server can be shutdown
+func (w *watcher) close() {
+	w.closeOnce.Do(func() { close(w.done) })
+}
+
+// ServeHTTP send server-sent event "reload" after each change of files
+func (w *watcher) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	ch := w.subscribe()
+	defer w.unsubscribe(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-w.done:
+			return
+		case <-ch:
+			fmt.Fprintf(rw, "event: reload\ndata: \n\n")
+			flusher.Flush()
+		}
+	}
+}
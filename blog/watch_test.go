@@ -0,0 +1,127 @@
+package blog
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"a.md":        "# A\n",
+		".git/HEAD":   "ref",
+		"sub/b.md":    "# B\n",
+		"sub/img.png": "png",
+	})
+
+	w, err := newWatcher(dir, isHidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(expect bool) {
+		t.Helper()
+		changed, err := w.check()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != expect {
+			t.Fatalf("changed is not same: %v != %v", changed, expect)
+		}
+	}
+
+	check(false)
+
+	// ignore git folder and hidden swap files of editors
+	createTree(t, dir, map[string]string{".git/HEAD": "ref changed", "sub/.b.md.swp": "swap"})
+	check(false)
+
+	// modify file
+	createTree(t, dir, map[string]string{"a.md": "# A modified\n"})
+	check(true)
+	check(false)
+
+	// new file
+	createTree(t, dir, map[string]string{"sub/c.md": "# C\n"})
+	check(true)
+
+	// remove file
+	if err := os.Remove(filepath.Join(dir, "sub", "b.md")); err != nil {
+		t.Fatal(err)
+	}
+	check(true)
+	check(false)
+}
+
+func TestWatcherEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{"a.md": "# A\n"})
+
+	w, err := newWatcher(dir, isHidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(w)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + reload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("not valid content type: %s", ct)
+	}
+
+	// wait subscription of client
+	for i := 0; ; i++ {
+		w.mu.Lock()
+		size := len(w.clients)
+		w.mu.Unlock()
+		if size == 1 {
+			break
+		}
+		if 100 < i {
+			t.Fatalf("client is not subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	createTree(t, dir, map[string]string{"b.md": "# B\n"})
+	if _, err := w.check(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, "event: reload") {
+		t.Errorf("not valid event: %s", line)
+	}
+
+	// stream is finished after close
+	w.close()
+	if _, err := ioutil.ReadAll(reader); err != nil {
+		t.Errorf("stream is not finished: %v", err)
+	}
+}
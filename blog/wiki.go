@@ -0,0 +1,194 @@
+package blog
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shurcooL/sanitized_anchor_name"
+)
+
+// wikiLink is wiki link to article by title or path with optional
+// heading: [[Article Title]], [[path/to/note#Heading]]
+var wikiLink = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// wiki is index of articles for resolving of wiki links
+type wiki struct {
+	// articles is articles by normalized titles and paths
+	articles map[string]article
+	// backlinks is articles with wiki links by path of linked article
+	backlinks map[string][]article
+}
+
+// wikiKey return normalized title or path of article
+func wikiKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Replace(name, "\\", "/", -1)
+	name = strings.TrimLeft(strings.TrimPrefix(name, "./"), "/")
+	return strings.TrimSuffix(name, ".md")
+}
+
+// newWiki return index of all articles from cache. Draft articles
+// are ignored if drafts is not set.
+func newWiki(c *cache, drafts bool) (w *wiki, err error) {
+	folders, err := c.list()
+	if err != nil {
+		return
+	}
+	w = &wiki{
+		articles:  map[string]article{},
+		backlinks: map[string][]article{},
+	}
+	var articles []article
+	for _, f := range folders {
+		for _, a := range f.articles {
+			if a.isDraft() && !drafts {
+				continue
+			}
+			articles = append(articles, a)
+			// paths are more specific than titles
+			w.articles[wikiKey(a.path)] = a
+		}
+	}
+	for _, a := range articles {
+		if _, ok := w.articles[wikiKey(a.name)]; !ok {
+			w.articles[wikiKey(a.name)] = a
+		}
+	}
+	for _, a := range articles {
+		e, err := c.get(a.path)
+		if err != nil || e.err != nil {
+			continue
+		}
+		used := map[string]bool{}
+		for _, target := range c.s.wikiTargets(e.body) {
+			t, _, ok := w.find(target)
+			if !ok || t.path == a.path || used[t.path] {
+				continue
+			}
+			used[t.path] = true
+			w.backlinks[t.path] = append(w.backlinks[t.path], a)
+		}
+	}
+	for path := range w.backlinks {
+		sortArticles(w.backlinks[path])
+	}
+	return
+}
+
+// find return article and anchor of heading by target of wiki link
+func (w *wiki) find(target string) (a article, anchor string, ok bool) {
+	name := target
+	if index := strings.Index(target, "#"); 0 <= index {
+		name = target[:index]
+		anchor = sanitized_anchor_name.Create(target[index+1:])
+	}
+	a, ok = w.articles[wikiKey(name)]
+	return
+}
+
+// wikiPlaceholder is placeholder of wiki link in markdown
+var wikiPlaceholder = regexp.MustCompile(`wikiplaceholder([0-9]+)x`)
+
+// wikiTargets return targets of all wiki links in markdown
+func (s *Server) wikiTargets(markdown []byte) (targets []string) {
+	s.wikiReplace(markdown, func(target string) string {
+		targets = append(targets, target)
+		return ""
+	})
+	return
+}
+
+// wikiReplace replace wiki links in markdown outside of code blocks,
+// code spans and raw html
+func (s *Server) wikiReplace(markdown []byte, replace func(target string) string) []byte {
+	// links is wiki links by index of placeholder
+	var links []string
+	lines := strings.SplitAfter(string(markdown), "\n")
+	var fence string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		// odd parts are code spans
+		parts := strings.Split(line, "`")
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = wikiLink.ReplaceAllStringFunc(parts[j], func(link string) string {
+				links = append(links, link)
+				return fmt.Sprintf("wikiplaceholder%dx", len(links)-1)
+			})
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+	out := []byte(strings.Join(lines, ""))
+	if len(links) == 0 {
+		return out
+	}
+
+	// indented code blocks and raw html are found by markdown parser
+	code := s.codePlaceholders(out, wikiPlaceholder)
+	return replacePlaceholders(out, wikiPlaceholder, func(index int) string {
+		link := links[index]
+		if code[index] {
+			return link
+		}
+		return replace(strings.TrimSpace(link[2 : len(link)-2]))
+	})
+}
+
+// wikiLinks return markdown of article with wiki links converted to
+// markdown links relative to folder of article. Unresolved links are
+// marked by class "wiki-missing".
+func (s *Server) wikiLinks(w *wiki, markdown []byte, from string) []byte {
+	return s.wikiReplace(markdown, func(target string) string {
+		a, anchor, ok := w.find(target)
+		if strings.HasPrefix(target, "#") {
+			// heading of same article
+			return fmt.Sprintf("[%s](#%s)", target[1:], anchor)
+		}
+		if !ok {
+			return fmt.Sprintf("<span class=\"wiki-missing\" title=\"Article is not found\">%s</span>",
+				html.EscapeString(target))
+		}
+		link := (&url.URL{Path: relativePath(from, a.path)}).String()
+		if anchor != "" {
+			link += "#" + anchor
+		}
+		return fmt.Sprintf("[%s](%s)", target, link)
+	})
+}
+
+// relativePath return path of target relative to folder of article
+func relativePath(from, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(target))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
+
+// backlinksHTML return html of list of articles with links to article
+func backlinksHTML(articles []article, l links) []byte {
+	if len(articles) == 0 {
+		return nil
+	}
+	var out string
+	out += "<nav class=\"backlinks\">\n<h2>Linked from</h2>\n<ul>\n"
+	for _, a := range articles {
+		out += fmt.Sprintf("<li><a href=\"%s\">%s</a></li>\n",
+			html.EscapeString(l.article(a.path)), html.EscapeString(a.name))
+	}
+	out += "</ul>\n</nav>\n"
+	return []byte(out)
+}
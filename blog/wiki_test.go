@@ -0,0 +1,120 @@
+package blog
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWikiLinks(t *testing.T) {
+	s := newTestServer(t, Options{})
+	defer s.Close()
+
+	w := &wiki{articles: map[string]article{}}
+	for _, a := range []article{
+		{path: "./notes/go.md", name: "Go language"},
+		{path: "./b.md", name: "Other"},
+	} {
+		w.articles[wikiKey(a.path)] = a
+		w.articles[wikiKey(a.name)] = a
+	}
+
+	tcs := []struct {
+		markdown string
+		expect   string
+	}{
+		{"[[Go language]]", "[Go language](notes/go.md)"},
+		{"[[ go LANGUAGE ]]", "[go LANGUAGE](notes/go.md)"},
+		{"[[notes/go]]", "[notes/go](notes/go.md)"},
+		{"[[./notes/go.md]]", "[./notes/go.md](notes/go.md)"},
+		{"[[notes/go#Hello World]]", "[notes/go#Hello World](notes/go.md#hello-world)"},
+		{"[[#Local heading]]", "[Local heading](#local-heading)"},
+		{"a [[Other]] b [[b]]", "a [Other](b.md) b [b](b.md)"},
+		{"[[Absent <note>]]", `<span class="wiki-missing" title="Article is not found">Absent &lt;note&gt;</span>`},
+		{"`[[Other]]` [[Other]]", "`[[Other]]` [Other](b.md)"},
+		{"```\n[[Other]]\n```\n[[Other]]", "```\n[[Other]]\n```\n[Other](b.md)"},
+		{"text\n\n    see [[Other]]\n\n[[Other]]", "text\n\n    see [[Other]]\n\n[Other](b.md)"},
+		{"<div>\n[[Other]]\n</div>\n\n[[Other]]", "<div>\n[[Other]]\n</div>\n\n[Other](b.md)"},
+		{"- item\n\n    [[Other]]", "- item\n\n    [Other](b.md)"},
+		{"[Other](link) [[]]", "[Other](link) [[]]"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.markdown, func(t *testing.T) {
+			out := string(s.wikiLinks(w, []byte(tc.markdown), "./a.md"))
+			if out != tc.expect {
+				t.Errorf("not valid markdown:\n%s\n%s", out, tc.expect)
+			}
+		})
+	}
+}
+
+func TestBacklinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	createTree(t, dir, map[string]string{
+		"a.md":       "# A\n\nSee [[B]] and [[B#Part]] and [[C]].\n",
+		"sub/b.md":   "# B\n\n## Part\n\nSee [[B]].\n",
+		"draft.md":   "---\ndraft: true\n---\n# Draft\n\n[[B]]\n",
+		"sub/old.md": "# Old\n\n`[[B]]`\n\n    [[B]]\n",
+	})
+	s := newTestServer(t, Options{Root: dir})
+	defer s.Close()
+
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		s.articleHandler(w, httptest.NewRequest("GET", url, nil))
+		return w.Body.String()
+	}
+
+	body := get("/articles/sub/b.md")
+	if strings.Count(body, `<li><a href="/articles/.%2Fa.md">A</a></li>`) != 1 {
+		t.Errorf("not valid backlinks:\n%s", body)
+	}
+	for _, c := range []string{"Draft", "Old", `<li><a href="/articles/.%2Fsub%2Fb.md">`} {
+		if strings.Contains(body, c) {
+			t.Errorf("found `%s` in:\n%s", c, body)
+		}
+	}
+	if body := get("/articles/a.md"); strings.Contains(body, "Linked from") ||
+		!strings.Contains(body, `<a href="/articles/.%2Fsub%2Fb.md#part">B#Part</a>`) ||
+		!strings.Contains(body, `<span class="wiki-missing" title="Article is not found">C</span>`) {
+		t.Errorf("not valid article:\n%s", body)
+	}
+
+	// new article is resolved after invalidation
+	createTree(t, dir, map[string]string{"c.md": "# C\n"})
+	s.cache.invalidate()
+	if body := get("/articles/a.md"); !strings.Contains(body, `<a href="/articles/.%2Fc.md">C</a>`) {
+		t.Errorf("new article is not linked:\n%s", body)
+	}
+	if body := get("/articles/c.md"); !strings.Contains(body, "Linked from") {
+		t.Errorf("backlinks of new article are not found:\n%s", body)
+	}
+
+	// static site
+	output := filepath.Join(dir, "public")
+	if err := s.Build(output); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(output, "articles", "sub", "b.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<li><a href="../../articles/a.html">A</a></li>`) {
+		t.Errorf("not valid static backlinks:\n%s", string(content))
+	}
+	content, err = ioutil.ReadFile(filepath.Join(output, "articles", "a.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<a href="../articles/sub/b.html#part">B#Part</a>`) {
+		t.Errorf("not valid static wiki link:\n%s", string(content))
+	}
+}
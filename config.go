@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Konstantin8105/md/blog"
+	"github.com/Konstantin8105/md/internal/values"
+	"github.com/russross/blackfriday"
+)
+
+// configFiles is names of configuration files in root folder.
+// First existing file is used.
+var configFiles = []string{"md.toml", "md.json"}
+
+// extensionNames is names of markdown extensions in configuration
+var extensionNames = map[string]blackfriday.Extensions{
+	"no-intra-emphasis":          blackfriday.NoIntraEmphasis,
+	"tables":                     blackfriday.Tables,
+	"fenced-code":                blackfriday.FencedCode,
+	"autolink":                   blackfriday.Autolink,
+	"strikethrough":              blackfriday.Strikethrough,
+	"lax-html-blocks":            blackfriday.LaxHTMLBlocks,
+	"space-headings":             blackfriday.SpaceHeadings,
+	"hard-line-break":            blackfriday.HardLineBreak,
+	"tab-size-eight":             blackfriday.TabSizeEight,
+	"footnotes":                  blackfriday.Footnotes,
+	"no-empty-line-before-block": blackfriday.NoEmptyLineBeforeBlock,
+	"heading-ids":                blackfriday.HeadingIDs,
+	"titleblock":                 blackfriday.Titleblock,
+	"auto-heading-ids":           blackfriday.AutoHeadingIDs,
+	"backslash-line-break":       blackfriday.BackslashLineBreak,
+	"definition-lists":           blackfriday.DefinitionLists,
+	"common":                     blackfriday.CommonExtensions,
+}
+
+// config is settings of blog from configuration file "md.toml" or
+// "md.json" in root folder:
+//
+//	title = "My blog"
+//	url = "https://example.com"
+//	addr = ":8080"
+//	cert = "cert.pem"
+//	key = "key.pem"
+//	extensions = ["common", "auto-heading-ids", "footnotes"]
+//	theme = "theme"
+//	photos = "photos"
+//	ignore = ["vendor", "node_modules"]
+//	search = true
+//	log = "access.log"
+//	logformat = "json"
+//
+// Flags override values of configuration file.
+type config struct {
+	Title      string
+	URL        string
+	Addr       string
+	Cert       string
+	Key        string
+	Extensions []string
+	Theme      string
+	Photos     string
+	Ignore     []string
+	Feed       int
+	// Log is file of access log. Empty is standard output.
+	Log string
+	// LogFormat is format of access log: "common" or "json"
+	LogFormat string
+	// feature toggles
+	Drafts    bool
+	Watch     bool
+	Search    bool
+	Feeds     bool
+	Highlight bool
+	TOC       bool
+	Math      bool
+}
+
+// defaultConfig return settings used without configuration file
+func defaultConfig() config {
+	return config{
+		Title:      "List of articles",
+		Addr:       ":8080",
+		Extensions: []string{"common", "auto-heading-ids"},
+		Photos:     "photos",
+		Feed:       20,
+		LogFormat:  blog.LogCommon,
+		Search:     true,
+		Feeds:      true,
+		Highlight:  true,
+		TOC:        true,
+		Math:       true,
+	}
+}
+
+// loadConfig return settings from configuration file in folder.
+// Default settings are returned if configuration file is not exist.
+func loadConfig(dir string) (c config, err error) {
+	c = defaultConfig()
+	for _, name := range configFiles {
+		filename := filepath.Join(dir, name)
+		var content []byte
+		content, err = ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			err = nil
+			continue
+		}
+		if err == nil {
+			err = c.parse(content, filepath.Ext(name))
+		}
+		if err != nil {
+			err = fmt.Errorf("Cannot load config `%s`: %v", filename, err)
+		}
+		return
+	}
+	return
+}
+
+// parse values of configuration in TOML format with extension ".toml"
+// or in JSON format with extension ".json". Only top-level keys with
+// simple values and lists are supported, same as in front matter of
+// articles.
+func (c *config) parse(content []byte, ext string) (err error) {
+	if ext == ".json" {
+		var values map[string]interface{}
+		if err = json.Unmarshal(content, &values); err != nil {
+			return
+		}
+		for key, value := range values {
+			var vs []string
+			switch v := value.(type) {
+			case []interface{}:
+				for _, item := range v {
+					vs = append(vs, fmt.Sprint(item))
+				}
+			default:
+				vs = []string{fmt.Sprint(v)}
+			}
+			if err = c.set(strings.ToLower(key), vs); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	lines := strings.Split(strings.Replace(string(content), "\r", "", -1), "\n")
+	return values.Parse(lines, "=", 1, c.set)
+}
+
+// set value of configuration by key
+func (c *config) set(key string, values []string) (err error) {
+	value := strings.Join(values, " ")
+	switch key {
+	case "title":
+		c.Title = value
+	case "url", "baseurl":
+		c.URL = strings.TrimSuffix(value, "/")
+	case "addr":
+		c.Addr = value
+	case "cert":
+		c.Cert = value
+	case "key":
+		c.Key = value
+	case "extensions":
+		for _, name := range values {
+			if _, ok := extensionNames[name]; !ok {
+				return fmt.Errorf("unknown markdown extension `%s`", name)
+			}
+		}
+		c.Extensions = values
+	case "theme":
+		c.Theme = value
+	case "photos":
+		c.Photos = value
+	case "ignore":
+		c.Ignore = values
+	case "feed":
+		c.Feed, err = strconv.Atoi(value)
+	case "log":
+		c.Log = value
+	case "logformat", "log-format":
+		c.LogFormat = value
+	case "drafts":
+		c.Drafts, err = strconv.ParseBool(value)
+	case "watch":
+		c.Watch, err = strconv.ParseBool(value)
+	case "search":
+		c.Search, err = strconv.ParseBool(value)
+	case "feeds":
+		c.Feeds, err = strconv.ParseBool(value)
+	case "highlight":
+		c.Highlight, err = strconv.ParseBool(value)
+	case "toc":
+		c.TOC, err = strconv.ParseBool(value)
+	case "math":
+		c.Math, err = strconv.ParseBool(value)
+	default:
+		err = fmt.Errorf("unknown key `%s`", key)
+	}
+	return
+}
+
+// options return options of blog by settings
+func (c config) options() (opts blog.Options) {
+	opts.Title = c.Title
+	opts.URL = c.URL
+	for _, name := range c.Extensions {
+		opts.Extensions |= extensionNames[name]
+	}
+	opts.Theme = c.Theme
+	opts.Photos = c.Photos
+	opts.Ignore = c.Ignore
+	opts.FeedSize = c.Feed
+	opts.Drafts = c.Drafts
+	opts.LiveReload = c.Watch
+	opts.NoSearch = !c.Search
+	opts.NoFeeds = !c.Feeds
+	opts.NoHighlight = !c.Highlight
+	opts.NoTOC = !c.TOC
+	opts.NoMath = !c.Math
+	return
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Konstantin8105/md/blog"
+	"github.com/russross/blackfriday"
+)
+
+// createTree create files in folder
+func createTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tcs := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "md.toml",
+			content: `# blog settings
+title = "My blog"
+url = "https://example.com/"
+addr = "localhost:9090"
+extensions = ["common", "footnotes"]
+photos = 'images'
+ignore = ["vendor", "node_modules"]
+feed = 5
+search = false
+toc = false
+math = false
+`,
+		},
+		{
+			name: "md.json",
+			content: `{
+	"title": "My blog",
+	"url": "https://example.com",
+	"addr": "localhost:9090",
+	"extensions": ["common", "footnotes"],
+	"photos": "images",
+	"ignore": ["vendor", "node_modules"],
+	"feed": 5,
+	"search": false,
+	"toc": false,
+	"math": false
+}`,
+		},
+	}
+	expect := defaultConfig()
+	expect.Title = "My blog"
+	expect.URL = "https://example.com"
+	expect.Addr = "localhost:9090"
+	expect.Extensions = []string{"common", "footnotes"}
+	expect.Photos = "images"
+	expect.Ignore = []string{"vendor", "node_modules"}
+	expect.Feed = 5
+	expect.Search = false
+	expect.TOC = false
+	expect.Math = false
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "md-config")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			createTree(t, dir, map[string]string{tc.name: tc.content})
+
+			c, err := loadConfig(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c, expect) {
+				t.Errorf("not valid config:\n%#v\n%#v", c, expect)
+			}
+		})
+	}
+}
+
+func TestConfigDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := loadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, defaultConfig()) {
+		t.Errorf("not default config: %#v", c)
+	}
+}
+
+func TestConfigError(t *testing.T) {
+	tcs := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"md.toml", "unknown = 1", "unknown key"},
+		{"md.toml", "extensions = [\"tables\", \"wrong\"]", "unknown markdown extension"},
+		{"md.toml", "search = maybe", "line 1"},
+		{"md.toml", "title", "not valid line"},
+		{"md.json", "{\"title\": ", "unexpected end"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.content, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "md-config")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			createTree(t, dir, map[string]string{tc.name: tc.content})
+
+			_, err = loadConfig(dir)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("not valid error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "md-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	createTree(t, dir, map[string]string{
+		"a/a.md":      "# A\n",
+		"vendor/b.md": "# B\n",
+	})
+
+	c := defaultConfig()
+	c.Extensions = []string{"tables"}
+	c.Ignore = []string{"vendor"}
+	c.Photos = "images"
+	c.Search = false
+	opts := c.options()
+
+	if opts.Extensions != blackfriday.Tables {
+		t.Errorf("not valid extensions: %v", opts.Extensions)
+	}
+	if opts.Photos != "images" {
+		t.Errorf("not valid photos folder: %s", opts.Photos)
+	}
+	if !opts.NoSearch || opts.NoFeeds {
+		t.Errorf("not valid toggles: %#v", opts)
+	}
+
+	opts.Root = dir
+	b, err := blog.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if body := w.Body.String(); !strings.Contains(body, "a.md") || strings.Contains(body, "b.md") {
+		t.Errorf("ignored folder is found:\n%s", body)
+	}
+}
@@ -5,5 +5,5 @@ go 1.12
 require (
 	github.com/Konstantin8105/cs v0.0.0-20190517091010-c069cc1cee1b
 	github.com/russross/blackfriday v2.0.0+incompatible
-	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
+	github.com/shurcooL/sanitized_anchor_name v1.0.0
 )
@@ -0,0 +1,79 @@
+// Package values parse simple values of front matter of articles and
+// configuration files.
+package values
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError is error of line without key and value
+type SyntaxError struct {
+	// Line is number of line
+	Line int
+	Text string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: not valid line `%s`", e.Line, e.Text)
+}
+
+// Parse parse lines "key: value" or "key = value" by separator
+// and call set for each key with values. Keys are in lower case.
+// Lists like `[a, "b"]` and YAML list items "- a" in next lines are
+// supported. TOML tables and comments are skipped. Number of first
+// line is used in errors. Error of line without key and value is
+// *SyntaxError.
+func Parse(lines []string, separator string, first int, set func(key string, values []string) error) error {
+	var key string
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// TOML tables are not supported
+			key = ""
+			continue
+		}
+		var values []string
+		switch index := strings.Index(line, separator); {
+		case strings.HasPrefix(line, "- ") && key != "":
+			// YAML list item
+			values = []string{unquote(line[2:])}
+		case index < 0:
+			return &SyntaxError{Line: first + i, Text: line}
+		default:
+			key = strings.ToLower(strings.TrimSpace(line[:index]))
+			value := strings.TrimSpace(line[index+1:])
+			if value == "" {
+				// values in next lines
+				continue
+			}
+			if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				for _, v := range strings.Split(value[1:len(value)-1], ",") {
+					if v = unquote(v); v != "" {
+						values = append(values, v)
+					}
+				}
+			} else {
+				values = []string{unquote(value)}
+			}
+		}
+		if err := set(key, values); err != nil {
+			return fmt.Errorf("line %d: %v", first+i, err)
+		}
+	}
+	return nil
+}
+
+// unquote remove spaces and quotes around value
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if 2 <= len(value) {
+		if f, l := value[0], value[len(value)-1]; f == l && (f == '"' || f == '\'') {
+			value = value[1 : len(value)-1]
+		}
+	}
+	return value
+}
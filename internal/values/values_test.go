@@ -0,0 +1,32 @@
+package values
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	values := map[string][]string{}
+	set := func(key string, vs []string) error {
+		if key == "bad" {
+			return fmt.Errorf("bad value")
+		}
+		values[key] = append(values[key], vs...)
+		return nil
+	}
+	lines := []string{"# comment", "Title = 'A = B'", "[table]", "list = [a, \"b\", ]", "items =", "- c", "  - 'd'"}
+	if err := Parse(lines, "=", 1, set); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := fmt.Sprintf("%v", values), "map[items:[c d] list:[a b] title:[A = B]]"; a != b {
+		t.Errorf("not valid values: %s != %s", a, b)
+	}
+
+	err := Parse([]string{"a = 1", "text"}, "=", 5, set)
+	if e, ok := err.(*SyntaxError); !ok || e.Line != 6 || e.Text != "text" {
+		t.Errorf("not valid syntax error: %#v", err)
+	}
+	if err := Parse([]string{"bad = 1"}, "=", 3, set); err == nil || err.Error() != "line 3: bad value" {
+		t.Errorf("not valid error: %v", err)
+	}
+}
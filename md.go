@@ -3,66 +3,34 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
+	"io"
+	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"runtime"
-	"sort"
 	"strings"
+	"syscall"
 
-	"github.com/russross/blackfriday"
+	"github.com/Konstantin8105/md/blog"
 )
 
-// Windows OS specific variable name
-const windowsOs string = "windows"
-
-// photos folders
-const photos string = "photos"
-
-func IsPhotos(str string) bool {
-	return strings.Contains(str, string(filepath.Separator)+photos)
-}
-
-var tmpl = `
-<html>
-	<head>
-		<meta name="viewport" content="width=device-width, initial-scale=1">
-		<style>
-			.markdown-body {
-				box-sizing: border-box;
-				min-width: 200px;
-				max-width: 900px;
-				margin: 0 auto;
-				padding: 45px;
-			}
-			@media (max-width: 767px) {
-				.markdown-body {
-					padding: 15px;
-				}
-			}
-			img{
-				max-height:500px;
-				max-width:500px;
-				height:auto;
-				width:auto;
-			}
-	</style>
-	</head>
-	<body>
-		<article class="markdown-body">
-			%s
-		</article>
-	</body>
-</html>`
-
 func main() {
 	// create flags
 	var (
-		help  = flag.Bool("h", false, "print help information")
-		port  = flag.String("p", "8080", "server port")
-		chdir = flag.String("ch", ".", "changes the current working directory to the named directory")
+		help   = flag.Bool("h", false, "print help information")
+		port   = flag.String("p", "8080", "server port")
+		addr   = flag.String("addr", "", "server address, for example `localhost:8080`")
+		cert   = flag.String("cert", "", "certificate file for TLS")
+		key    = flag.String("key", "", "private key file for TLS")
+		logF   = flag.String("log", "", "file of access log, standard output by default")
+		logFmt = flag.String("log-format", blog.LogCommon, "format of access log: common or json")
+		chdir  = flag.String("ch", ".", "root folder with articles and photos")
+		watch  = flag.Bool("watch", false, "reload opened pages after changes of files on disk")
+		th     = flag.String("theme", "", "folder with html templates of pages")
+		draft  = flag.Bool("drafts", false, "show draft articles")
+		feed   = flag.Int("feed", 20, "maximal amount of articles in RSS and Atom feeds")
+		photo  = flag.String("photos", "photos", "name of photos folder")
+		ignore = flag.String("ignore", "", "comma-separated names of ignored folders")
 	)
 
 	// parsing flags
@@ -74,312 +42,177 @@ func main() {
 
 	// flag action
 	if *help {
+		fmt.Fprintf(os.Stdout, "Commands:\n")
+		fmt.Fprintf(os.Stdout, "  build -o <dir>\n\tgenerate static site in output folder\n")
+		fmt.Fprintf(os.Stdout, "  check\n\tcheck internal links, images and heading anchors of articles\n")
+		fmt.Fprintf(os.Stdout, "Flags:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stdout, "Configuration:\n")
+		fmt.Fprintf(os.Stdout, "  file %s in root folder, flags override values of file\n",
+			strings.Join(configFiles, " or "))
 		os.Exit(0)
 	}
 
-	if err := os.Chdir(*chdir); err != nil {
-		fmt.Fprintf(os.Stderr, "cannot change directory : %v", err)
-	}
-
-	// output used server port
-	fmt.Fprintf(os.Stdout, "Start server on port :%s\n", *port)
-
-	// prepare server
-
-	// generate main page
-	http.HandleFunc("/", mainHandler)
-	// generate articles
-	http.HandleFunc("/articles/", articleHandler)
-	// generate photos
-	http.HandleFunc("/"+photos+"/", photosHandler)
-
-	// start server
-	if err := http.ListenAndServe(":"+*port, nil); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error : %v", err)
+	// configuration file is located in root folder
+	conf, err := loadConfig(*chdir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
-}
-
-// mainHandler generate main web page with list of articles
-func mainHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(os.Stdout, "GET : %v\n", r.URL.Path)
-
-	if err := func() (err error) {
-		defer func() {
-			if err != nil {
-				err = fmt.Errorf("Try open page: %v. %v", r.URL.Path, err)
-			}
-		}()
-		// generate markdown main page
-		var mainTmpl string = "# List of articles:\n\n"
-		// get all folders
-		var getFolder func(string) ([]string, error)
-		getFolder = func(baseFolder string) (fs []string, err error) {
-			files, err := ioutil.ReadDir(baseFolder)
-			if err != nil {
-				return nil, err
-			}
-			for _, file := range files {
-				if !file.IsDir() {
-					continue
-				}
-				if file.Name() == ".git" {
-					continue
-				}
-				fs = append(fs, baseFolder+string(os.PathSeparator)+file.Name())
-			}
-			size := len(fs)
-			for i := 0; i < size; i++ {
-				fss, err := getFolder(fs[i])
-				if err != nil {
-					return nil, err
-				}
-				fs = append(fs, fss...)
-			}
-
-			return fs, nil
+	// files from configuration are relative to root folder
+	for _, name := range []*string{&conf.Theme, &conf.Cert, &conf.Key, &conf.Log} {
+		if *name != "" && !filepath.IsAbs(*name) {
+			*name = filepath.Join(*chdir, *name)
 		}
-		folders, err := getFolder(".")
-		if err != nil {
-			return err
-		}
-		folders = append(folders, ".")
-		sort.Strings(folders)
-
-		// find all markdown files
-		for i := range folders {
-			files, err := ioutil.ReadDir(folders[i])
-			if err != nil {
-				return err
-			}
-			sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
-			var folderHeader bool
-			for _, file := range files {
-				if file.IsDir() {
-					continue
-				}
-				if !strings.HasSuffix(file.Name(), ".md") {
-					continue
-				}
-
-				if !folderHeader {
-					folderHeader = true
-					mainTmpl += "------\n\n"
-					count := strings.Count(folders[i], "\\")
-					count += strings.Count(folders[i], "/")
-					count++
-					for i := 0; i < count && i < 3; i++ {
-						mainTmpl += "#"
-					}
-					f := folders[i]
-					if runtime.GOOS == windowsOs {
-						f = strings.Replace(f, "\\", "/", -1)
-					}
-					mainTmpl += fmt.Sprintf(" %s\n\n", f)
-				}
-				path := folders[i] + string(os.PathSeparator) + file.Name()
-
-				// Windows specific
-				if runtime.GOOS == windowsOs {
-					path = strings.Replace(path, "\\", "/", -1)
-				}
-
-				// get name of article
-				name := path
-				{
-					var content []byte
-					content, err = ioutil.ReadFile(path)
-					if err == nil {
-						title := string(content[:200])
-						index := strings.Index(title, "\n")
-						if index > 0 {
-							if title = strings.TrimSpace(title[:index]); title != "" {
-								name = title
-								name = strings.ReplaceAll(name, "#", " ")
-								name = strings.TrimSpace(name)
-							}
-						}
-					}
-				}
-
-				// escape space
-				path = url.QueryEscape(path)
-
-				// add to main page
-				mainTmpl += fmt.Sprintf("[%s](/articles/%s)\n\n", name, path)
-				mainTmpl += "\n\n"
-			}
-		}
-		mainTmpl += "------\n\n"
-
-		// photos
-		func() {
-			files, err := ioutil.ReadDir(photos)
-			if err != nil {
-				return
-			}
-			mainTmpl += fmt.Sprintf("# PHOTOS\n\n")
-			for _, file := range files {
-				name := file.Name()
-				mainTmpl += fmt.Sprintf("[%s](/photos/%s)\n\n", name, name)
-				mainTmpl += "\n\n"
-			}
-			mainTmpl += "------\n\n"
-		}()
-
-		// generate html by markdown
-		html := blackfriday.Run([]byte(mainTmpl))
-		fmt.Fprintf(w, tmpl, html)
-		return
-	}(); err != nil {
-		fmt.Fprintf(w, "Error : %v\n", err)
 	}
-}
-
-// articleHandler generate web page with article
-func articleHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(os.Stdout, "GET : %v\n", r.URL.Path)
-
-	if err := func() (err error) {
-		defer func() {
-			if err != nil {
-				err = fmt.Errorf("Try open page in article: %v. %v", r.URL.Path, err)
-			}
-		}()
-		// get title
-		path := r.URL.Path
-		if len(path) <= len("/articles/") {
-			err = fmt.Errorf("URL path is too small: %s", path)
-			return
+	// flags override values of configuration file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "p":
+			if *addr == "" {
+				conf.Addr = withPort(conf.Addr, *port)
+			}
+		case "addr":
+			conf.Addr = *addr
+		case "cert":
+			// files from flags are relative to folder of start
+			conf.Cert, _ = filepath.Abs(*cert)
+		case "key":
+			conf.Key, _ = filepath.Abs(*key)
+		case "log":
+			conf.Log, _ = filepath.Abs(*logF)
+		case "log-format":
+			conf.LogFormat = *logFmt
+		case "watch":
+			conf.Watch = *watch
+		case "theme":
+			conf.Theme = *th
+		case "drafts":
+			conf.Drafts = *draft
+		case "feed":
+			conf.Feed = *feed
+		case "photos":
+			conf.Photos = *photo
+		case "ignore":
+			conf.Ignore = strings.Split(*ignore, ",")
 		}
-		title := path[len("/articles/")-1:]
-		title = strings.TrimSpace(title)
-		if title == "" {
-			err = fmt.Errorf("Title of article is empty")
+	})
+	opts := conf.options()
+	opts.Root = *chdir
+
+	// commands
+	if 0 < flag.NArg() {
+		switch name := flag.Arg(0); name {
+		case "build":
+			fs := flag.NewFlagSet(name, flag.ExitOnError)
+			output := fs.String("o", "public", "output folder for static site")
+			if err := fs.Parse(flag.Args()[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			// output folder is relative to folder of start
+			if err := build(opts, *output); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
 			return
-		}
-		// Unescape url
-		title, err = url.QueryUnescape(title)
-		if title == "" {
-			err = fmt.Errorf("Cannot unescape : %v", err)
+		case "check":
+			if err := check(opts, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
 			return
+		default:
+			fmt.Fprintf(os.Stderr, "undefined command: %s\n", name)
+			os.Exit(1)
 		}
+	}
 
-		// secury fix of title
-		// avoid word ".."
-		title = strings.ReplaceAll(title, "..", "doubledot")
+	// prepare blog
+	b, err := blog.New(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer b.Close()
 
-		// Windows specific
-		if runtime.GOOS == windowsOs {
-			title = strings.Replace(title, "/", "\\", -1)
-		}
+	// output used server address
+	fmt.Fprintf(os.Stdout, "Start server on address %s\n", conf.Addr)
 
-		// fix first letter
-		if len(title) > 0 && (title[0] == '\\' || title[0] == '/') {
-			title = title[1:]
+	// access log
+	var out io.Writer = os.Stdout
+	if conf.Log != "" {
+		f, err := os.OpenFile(conf.Log, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot open access log : %v\n", err)
+			os.Exit(1)
 		}
+		defer f.Close()
+		out = f
+	}
+	handler, err := blog.NewAccessLog(b, out, conf.LogFormat, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 
-		// get file content
-		if strings.HasSuffix(title, ".md") {
-			var content []byte
-			content, err = ioutil.ReadFile(title)
-			if err != nil {
-				if runtime.GOOS == windowsOs {
-					title = strings.Replace(title, "\\", "/", -1)
-				}
-				err = fmt.Errorf("Cannot read file `%s`: %v", title, err)
-				return
-			}
-			//
-			str := string(content)
-			str = strings.Replace(str, "\r", "", -1)
-
-			// add link to main page
-			str = "[Main page](/)\n\n" + str
+	srv := newServer(handler, conf.Watch)
+	// streams of events are never idle, so close them before shutdown
+	srv.RegisterOnShutdown(func() { b.Close() })
 
-			// generate markdown
-			html := blackfriday.Run([]byte(str))
-			fmt.Fprintf(w, tmpl, html)
-		} else {
-			http.ServeFile(w, r, title)
-		}
-		return
-	}(); err != nil {
-		fmt.Fprintf(w, "Error : %v\n", err)
+	// start server
+	ln, err := net.Listen("tcp", conf.Addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Server error : %v\n", err)
+		os.Exit(1)
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	if err := serve(srv, ln, conf.Cert, conf.Key, stop); err != nil {
+		fmt.Fprintf(os.Stderr, "Server error : %v\n", err)
+		os.Exit(1)
 	}
 }
 
-// photosHandler generate web page with photos
-func photosHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(os.Stdout, "GET : %v\n", r.URL.Path)
-
-	if err := func() (err error) {
-		defer func() {
-			if err != nil {
-				err = fmt.Errorf("Try open page in photos: %v. %v", r.URL.Path, err)
-			}
-		}()
-		// get title
-		path := r.URL.Path
-		if len(path) <= len("/"+photos+"/") {
-			err = fmt.Errorf("URL path is too small: %s", path)
-			return
-		}
-		title := path[len("/"+photos+"/")-1:]
-		title = strings.TrimSpace(title)
-		if title == "" {
-			err = fmt.Errorf("Title of photos is empty")
-			return
-		}
-		// Unescape url
-		title, err = url.QueryUnescape(title)
-		if title == "" {
-			err = fmt.Errorf("Cannot unescape : %v", err)
-			return
-		}
-
-		// secury fix of title
-		// avoid word ".."
-		title = strings.ReplaceAll(title, "..", "doubledot")
-
-		// Windows specific
-		if runtime.GOOS == windowsOs {
-			title = strings.Replace(title, "/", "\\", -1)
-		}
+// build generate static site of blog in output folder
+func build(opts blog.Options, output string) error {
+	// static site is not watched
+	opts.LiveReload = false
+	b, err := blog.New(opts)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	return b.Build(output)
+}
 
-		// fix first letter
-		if len(title) > 0 && (title[0] == '\\' || title[0] == '/') {
-			title = title[1:]
-		}
+// withPort return address with port and host of address
+func withPort(addr, port string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = ""
+	}
+	return net.JoinHostPort(host, port)
+}
 
-		index := strings.LastIndex(title, string(filepath.Separator))
-		if index < 0 {
-			// folder list
-			f := photos + string(filepath.Separator) + title
-			files, err := ioutil.ReadDir(f)
-			if err != nil {
-				err = fmt.Errorf("readdir :`%s`. %v", f, err)
-				return err
-			}
-			var content string
-			content += "[Main page](/)\n\n"
-			content += fmt.Sprintf("%s\n\n", title)
-			for _, file := range files {
-				content += fmt.Sprintf("![%s](/%s/%s/%s)\n\n",
-					file.Name(),
-					photos,
-					title,
-					file.Name(),
-				)
-			}
-			html := blackfriday.Run([]byte(content))
-			fmt.Fprintf(w, tmpl, html)
-		} else {
-			// view file
-			http.ServeFile(w, r, photos+string(filepath.Separator)+title)
-		}
-		return
-	}(); err != nil {
-		fmt.Fprintf(w, "Error : %v\n", err)
+// check write report of broken links in articles. Error is returned
+// if any problem is found.
+func check(opts blog.Options, out io.Writer) error {
+	opts.LiveReload = false
+	b, err := blog.New(opts)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	ps, err := b.Check()
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		fmt.Fprintf(out, "%s\n", p)
+	}
+	if 0 < len(ps) {
+		return fmt.Errorf("found %d problems in articles", len(ps))
 	}
+	return nil
 }
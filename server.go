@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// timeouts of web server
+const (
+	readTimeout     = 10 * time.Second
+	writeTimeout    = 30 * time.Second
+	idleTimeout     = 2 * time.Minute
+	shutdownTimeout = 10 * time.Second
+)
+
+// newServer return web server with timeouts. Write timeout is disabled
+// for long-lived streams of events.
+func newServer(handler http.Handler, stream bool) *http.Server {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       readTimeout,
+		ReadHeaderTimeout: readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	if stream {
+		srv.WriteTimeout = 0
+	}
+	return srv
+}
+
+// serve accept connections on listener until signal in channel stop,
+// then shutdown server gracefully with waiting of in-flight requests.
+// TLS is used if certificate or key file is not empty.
+func serve(srv *http.Server, ln net.Listener, cert, key string, stop <-chan os.Signal) error {
+	errs := make(chan error, 1)
+	go func() {
+		if cert != "" || key != "" {
+			errs <- srv.ServeTLS(ln, cert, key)
+			return
+		}
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-stop:
+		fmt.Fprintf(os.Stdout, "Shutdown server : %v\n", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			return fmt.Errorf("Cannot shutdown server: %v", err)
+		}
+		return nil
+	}
+}
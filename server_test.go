@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServeShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() { served <- serve(newServer(mux, false), ln, "", "", stop) }()
+
+	// in-flight request
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	stop <- syscall.SIGTERM
+	// server is not stopped before end of request
+	select {
+	case err := <-served:
+		t.Fatalf("server is stopped with in-flight request: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	res := <-results
+	if res.err != nil || res.body != "done" {
+		t.Errorf("in-flight request is not drained: %q %v", res.body, res.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("not graceful shutdown: %v", err)
+	}
+
+	// new connections are refused
+	if _, err := http.Get("http://" + ln.Addr().String() + "/"); err == nil {
+		t.Errorf("server accept requests after shutdown")
+	}
+}
+
+func TestServeTLSError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	err = serve(newServer(http.NewServeMux(), false), ln, "not-exist.pem", "not-exist.key", nil)
+	if err == nil || !strings.Contains(err.Error(), "not-exist.pem") {
+		t.Errorf("not valid error: %v", err)
+	}
+}
+
+func TestServeStream(t *testing.T) {
+	if srv := newServer(nil, false); srv.WriteTimeout == 0 {
+		t.Errorf("write timeout is not set")
+	}
+	if srv := newServer(nil, true); srv.WriteTimeout != 0 {
+		t.Errorf("write timeout is set for streams")
+	}
+}